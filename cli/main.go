@@ -3,14 +3,276 @@
 package main
 
 import (
+	"cmp"
+	"flag"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
 
+	"github.com/hkupty/mirkwood/pkg/catalog"
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+	"github.com/hkupty/mirkwood/pkg/maze/gen"
 	"github.com/hkupty/mirkwood/pkg/tui"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		if err := formatPrograms(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "gen" {
+		if err := generateLevel(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rate" {
+		if err := rateLevels(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	program := flag.String("program", "", "file with the program to run on the level")
+	lang := flag.String("lang", defaultDialect(), "language programs and messages are written in: pt-BR, en or es")
+	disasm := flag.Bool("disasm", false, "print the compiled program instead of playing")
+	flag.Parse()
+
 	fmt.Println("Mirkwood - Educational Maze Game")
-	fmt.Println("Run with: go run cli/main.go")
+	fmt.Println("Run with: go run cli/main.go [-program program.txt [-disasm]] [level.txt]")
+	fmt.Println("      or: go run cli/main.go fmt [-ascii] [-w] [-lang pt-BR] [-to en] program.txt...")
+	fmt.Println("      or: go run cli/main.go gen -seed 4711 [-algorithm backtracker] [-rows 7] [-cols 7] [-braid 0.5] [-concept marks] > level.txt")
+	fmt.Println("      or: go run cli/main.go rate level.txt...")
+
+	bp := maze.SampleBlueprint
+	if flag.NArg() > 0 {
+		level, err := loadLevel(flag.Arg(0))
+		if err != nil {
+			fmt.Printf("Could not load level %s: %v\n", flag.Arg(0), err)
+			os.Exit(1)
+		}
+		bp = level
+	}
+
+	var src string
+	if *program != "" {
+		content, err := os.ReadFile(*program)
+		if err != nil {
+			fmt.Printf("Could not load program %s: %v\n", *program, err)
+			os.Exit(1)
+		}
+		src = string(content)
+	}
+
+	dialect, err := command.DialectFor(*lang)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	messages, err := catalog.For(*lang)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *disasm {
+		if err := disassemble(src, dialect); err != nil {
+			fmt.Printf("Could not compile program %s: %v\n", *program, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	tui.MainLoop(bp, src, dialect, messages)
+}
+
+// defaultDialect picks the language from the MIRKWOOD_LANG environment variable, so each profile can set its own.
+func defaultDialect() string {
+	if lang := os.Getenv("MIRKWOOD_LANG"); lang != "" {
+		return lang
+	}
+	return command.Dialects[0].Tag
+}
+
+func disassemble(src string, dialect *command.Dialect) error {
+	program, err := dialect.Parse(src)
+	if err != nil {
+		return err
+	}
+
+	code, err := command.Compile(program)
+	if err != nil {
+		return err
+	}
+
+	return code.Disassemble(os.Stdout)
+}
+
+func loadLevel(path string) (maze.LevelBlueprint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return maze.LevelBlueprint{}, err
+	}
+	defer file.Close()
+
+	return maze.ReadLevel(file)
+}
+
+// generateLevel implements the `gen` subcommand, printing the level generated from a seed along with the shape of its maze.
+func generateLevel(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	seed := flags.Uint("seed", 0, "seed picking the maze, the same seed always gives the same maze")
+	algorithm := flags.String("algorithm", gen.Algorithms[0].String(), "how the maze is carved: backtracker, prim or wilson")
+	rows := flags.Int("rows", 7, "number of rooms from top to bottom")
+	cols := flags.Int("cols", 7, "number of rooms from left to right")
+	braid := flags.Float64("braid", 0, "fraction of dead ends opened into loops, from 0 to 1")
+	concept := flags.String("concept", gen.Concepts[0].String(), "what the maze teaches: any, repetition, conditions or marks")
+	reference := flags.Bool("reference", false, "also print a program solving the level with the concept it teaches")
+	flags.Parse(args)
+
+	if *seed > math.MaxUint32 {
+		return fmt.Errorf("seed %d is too large, it must be up to %d", *seed, uint32(math.MaxUint32))
+	}
+
+	opts := gen.Options{Seed: uint32(*seed), Rows: *rows, Cols: *cols, Braid: *braid}
+	var err error
+	if opts.Algorithm, err = gen.ParseAlgorithm(*algorithm); err != nil {
+		return err
+	}
+	if opts.Concept, err = gen.ParseConcept(*concept); err != nil {
+		return err
+	}
+
+	bp, report, err := gen.Generate(opts)
+	if err != nil {
+		return err
+	}
+
+	// The report goes to the standard error, so the level can be redirected to a file
+	fmt.Fprintf(os.Stderr, "%d loops, %d dead ends", report.Cycles, report.DeadEnds)
+	if par := bp.WinCondition.ParBlocks; par > 0 {
+		fmt.Fprintf(os.Stderr, ", par %d blocks", par)
+	}
+	fmt.Fprintln(os.Stderr)
+	if *reference && report.Reference != "" {
+		fmt.Fprint(os.Stderr, report.Reference)
+	}
+	return maze.WriteLevel(os.Stdout, bp)
+}
+
+// rateLevels implements the `rate` subcommand, listing the level files from the easiest to the hardest.
+// Levels are sorted by how long and winding their way out is, then by how many choices there are along the way.
+// The size of the smallest program walking out breaks ties.
+func rateLevels(paths []string) error {
+	type rated struct {
+		path   string
+		report maze.DifficultyReport
+	}
+
+	levels := make([]rated, 0, len(paths))
+	for _, path := range paths {
+		bp, err := loadLevel(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		report, err := maze.Difficulty(bp)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		levels = append(levels, rated{path, report})
+	}
+
+	slices.SortStableFunc(levels, func(a, b rated) int {
+		return cmp.Or(
+			cmp.Compare(a.report.PathLength, b.report.PathLength),
+			cmp.Compare(a.report.Turns, b.report.Turns),
+			cmp.Compare(a.report.Junctions+a.report.Loops, b.report.Junctions+b.report.Loops),
+			cmp.Compare(a.report.Blocks, b.report.Blocks),
+		)
+	})
+
+	for _, l := range levels {
+		r := l.report
+		fmt.Printf("%s: %d steps, %d turns, longest run %d, %d junctions, %d dead ends, %d loops, %d blocks\n",
+			l.path, r.PathLength, r.Turns, r.LongestRun, r.Junctions, r.DeadEnds, r.Loops, r.Blocks)
+	}
+	return nil
+}
+
+// formatPrograms implements the `fmt` subcommand, printing each program in its canonical form,
+// or rewriting the files with -w. Reads from the standard input when there are no files.
+func formatPrograms(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	ascii := flags.Bool("ascii", false, "write ASCII arrows and keywords without accents")
+	write := flags.Bool("w", false, "write the result back to the files instead of printing it")
+	lang := flags.String("lang", defaultDialect(), "dialect the programs are written in: pt-BR, en or es")
+	to := flags.String("to", "", "dialect to translate the programs to, keeping their own when empty")
+	flags.Parse(args)
+
+	dialect, err := command.DialectFor(*lang)
+	if err != nil {
+		return err
+	}
+
+	opts := command.FormatOptions{}
+	if *ascii {
+		opts.Arrows = command.ASCIIArrows
+	}
+	if *to != "" {
+		if opts.Dialect, err = command.DialectFor(*to); err != nil {
+			return err
+		}
+	}
+
+	if flags.NArg() == 0 {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := formatSource(string(content), dialect, opts)
+		if err != nil {
+			return fmt.Errorf("<stdin>:%w", err)
+		}
+		_, err = os.Stdout.WriteString(formatted)
+		return err
+	}
+
+	for _, path := range flags.Args() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := formatSource(string(content), dialect, opts)
+		if err != nil {
+			return fmt.Errorf("%s:%w", path, err)
+		}
+
+		if *write {
+			err = os.WriteFile(path, []byte(formatted), 0o644)
+		} else {
+			_, err = os.Stdout.WriteString(formatted)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	tui.MainLoop()
+func formatSource(src string, dialect *command.Dialect, opts command.FormatOptions) (string, error) {
+	program, err := dialect.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return command.Format(program, opts), nil
 }
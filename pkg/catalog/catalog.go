@@ -0,0 +1,109 @@
+// Package catalog holds the messages shown to the player, written in each of the languages the game speaks.
+// Errors carry a Key and its arguments instead of a sentence, so the same error can be rendered
+// in whichever language was chosen at startup.
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownCatalog indicates a language tag that doesn't match any of the Catalogs
+var ErrUnknownCatalog = errors.New("unknown catalog")
+
+// Key identifies a message, prefixed by the package reporting it, such as "core.hit_wall".
+type Key string
+
+// Catalog writes every message in one language.
+type Catalog struct {
+	// Tag identifies the language, such as "pt-BR"
+	Tag string
+
+	// messages holds the template of each message, which refers to its arguments by position
+	messages map[Key]string
+}
+
+// Catalogs lists every catalog, the first one being the default.
+var Catalogs = []*Catalog{Portuguese, English, Spanish}
+
+// For returns the catalog with the given tag.
+func For(tag string) (*Catalog, error) {
+	for _, c := range Catalogs {
+		if c.Tag == tag {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownCatalog, tag)
+}
+
+// Text writes the message identified by key with args, or the key itself if c doesn't know it.
+func (c *Catalog) Text(key Key, args ...any) string {
+	template, ok := c.messages[key]
+	if !ok {
+		return string(key)
+	}
+	return fmt.Sprintf(template, args...)
+}
+
+// Localizer is implemented by errors that wrap another error with some context,
+// such as the position in the program where it happened, so they can be rendered by any Catalog.
+type Localizer interface {
+	Localize(c *Catalog) string
+}
+
+// Render writes err for the player in the language of c.
+// An *Error is written from its key, a Localizer writes itself and joined errors are written one per line.
+// Any other error is written as is.
+func (c *Catalog) Render(err error) string {
+	switch e := err.(type) {
+	case nil:
+		return ""
+	case *Error:
+		return c.Text(e.Key, e.Args...)
+	case Localizer:
+		return e.Localize(c)
+	case interface{ Unwrap() []error }:
+		var lines []string
+		for _, inner := range e.Unwrap() {
+			lines = append(lines, c.Render(inner))
+		}
+		return strings.Join(lines, "\n")
+	default:
+		return err.Error()
+	}
+}
+
+// Error is an error reported to the player, identified by Key and written by a Catalog.
+// Sentinel errors are created with New, and Detail adds specifics to them while keeping them usable with errors.Is.
+type Error struct {
+	Key  Key
+	Args []any
+
+	// base is the sentinel this error details
+	base *Error
+}
+
+// New creates a sentinel error for the message identified by key.
+func New(key Key) *Error {
+	return &Error{Key: key}
+}
+
+// Detail creates an error with a more specific message, identified by key and written with args,
+// which is still the same error as e for errors.Is.
+func (e *Error) Detail(key Key, args ...any) *Error {
+	return &Error{Key: key, Args: args, base: e}
+}
+
+// Error writes the message in English, for developers.
+// Players should get it through Catalog.Render instead.
+func (e *Error) Error() string {
+	return English.Text(e.Key, e.Args...)
+}
+
+func (e *Error) Unwrap() error {
+	if e.base == nil {
+		return nil
+	}
+	return e.base
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// verbs matches the arguments a template refers to
+var verbs = regexp.MustCompile(`%\[\d+\][a-z]`)
+
+func TestCatalogsAgree(t *testing.T) {
+	for _, c := range Catalogs {
+		t.Run(c.Tag, func(t *testing.T) {
+			keys := slices.Sorted(maps.Keys(c.messages))
+			expected := slices.Sorted(maps.Keys(English.messages))
+			if !slices.Equal(keys, expected) {
+				t.Fatalf("expected the keys %v, got %v", expected, keys)
+			}
+
+			// Translations may reorder arguments, but must use the same ones
+			for _, key := range keys {
+				used := slices.Compact(slices.Sorted(slices.Values(verbs.FindAllString(c.messages[key], -1))))
+				wanted := slices.Compact(slices.Sorted(slices.Values(verbs.FindAllString(English.messages[key], -1))))
+				if !slices.Equal(used, wanted) {
+					t.Errorf("%s: expected the arguments %v, got %v", key, wanted, used)
+				}
+			}
+		})
+	}
+}
+
+func TestFor(t *testing.T) {
+	for _, c := range Catalogs {
+		if found, err := For(c.Tag); err != nil || found != c {
+			t.Fatalf("expected %s to be found, got %v, %v", c.Tag, found, err)
+		}
+	}
+
+	if _, err := For("xx"); !errors.Is(err, ErrUnknownCatalog) {
+		t.Fatalf("expected ErrUnknownCatalog, got %v", err)
+	}
+}
+
+// positioned wraps an error the way errors pointing at the program do
+type positioned struct {
+	err error
+}
+
+func (p positioned) Error() string {
+	return "1:2: " + p.err.Error()
+}
+
+func (p positioned) Localize(c *Catalog) string {
+	return "1:2: " + c.Render(p.err)
+}
+
+func TestRender(t *testing.T) {
+	sentinel := New("core.missing_marks")
+	detailed := sentinel.Detail("core.missing_marks_count", 1, 3)
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"sentinel", sentinel, "faltou marcar algumas casas"},
+		{"detailed", detailed, "faltou marcar algumas casas: 1 de 3"},
+		{"localizer", positioned{detailed}, "1:2: faltou marcar algumas casas: 1 de 3"},
+		{"joined", errors.Join(New("core.hit_wall"), detailed), "você bateu em uma árvore\nfaltou marcar algumas casas: 1 de 3"},
+		{"unknown key", New("core.nothing"), "core.nothing"},
+		{"foreign", fmt.Errorf("%w: wrapped", sentinel), "not enough cells were marked: wrapped"},
+		{"nil", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if text := Portuguese.Render(tc.err); text != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, text)
+			}
+		})
+	}
+}
+
+func TestDetail(t *testing.T) {
+	sentinel := New("core.step_limit")
+	detailed := sentinel.Detail("core.step_limit_count", 12, 10)
+
+	if !errors.Is(detailed, sentinel) {
+		t.Fatalf("expected %v to still be %v", detailed, sentinel)
+	}
+	if errors.Is(sentinel, detailed) || errors.Is(New("core.step_limit"), sentinel) {
+		t.Fatal("expected sentinels to be told apart by identity, not by key")
+	}
+	if expected := "you took more steps than allowed: 12 of 10"; detailed.Error() != expected {
+		t.Fatalf("expected developers to read %q, got %q", expected, detailed.Error())
+	}
+}
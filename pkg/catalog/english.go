@@ -0,0 +1,57 @@
+package catalog
+
+// English is the language for classrooms taught in English, and the one developers read errors in.
+var English = &Catalog{
+	Tag: "en",
+	messages: map[Key]string{
+		// Mistakes in player programs
+		"command.stray_brace":        "this `}` doesn't close any block",
+		"command.undefined_function": "there is no `%[1]s` called `%[2]s`",
+		"command.else_without_if":    "`%[1]s` must come right after the block of an `%[2]s`",
+		"command.lonely_number":      "a number alone doesn't do anything, did you mean `%[1]s %[2]s { ... }`?",
+		"command.illegal":            "I don't know what `%[1]s` means",
+		"command.unexpected":         "I don't know what to do with `%[1]s` here",
+		"command.turn_side":          "`%[1]s` needs to know which side to turn to: `%[1]s ←` or `%[1]s →`",
+		"command.repeat_count":       "`%[1]s` needs a number saying how many times, like `%[1]s 3 { → }`",
+		"command.repeat_too_many":    "that's too many times! Try a number up to %[1]d",
+		"command.condition_missing":  "`%[1]s` needs something to check, like `%[1]s %[2]s { ... }`",
+		"command.unknown_sensor":     "I don't know how to check `%[1]s`",
+		"command.nested_function":    "a `%[1]s` must be defined outside of any other block",
+		"command.function_name":      "`%[1]s` needs a name, like `%[1]s turn_and_go { %[2]s → %[3]s }`",
+		"command.name_is_sensor":     "`%[1]s` is already used to check the surroundings, pick another name",
+		"command.duplicate_function": "there is already a `%[1]s` called `%[2]s` at line %[3]d",
+		"command.block_open":         "I expected a `{` to start the block of `%[1]s` here",
+		"command.block_unclosed":     "this block was never closed, a `}` is missing",
+		"command.not_learned":        "you haven't learned `%[1]s` yet",
+		"command.too_many_blocks":    "your program uses %[1]d blocks, but this level only allows %[2]d",
+		"command.blocks_too_deep":    "your blocks go %[1]d levels deep, but this level only allows %[2]d",
+		"command.program_too_long":   "this program is too long, try using loops or functions",
+
+		// Running player programs
+		"command.infinite_loop":    "this loop would never stop",
+		"command.out_of_budget":    "the program ran for too long, this loop may never stop",
+		"command.too_deep":         "functions called each other too many times",
+		"command.sensor_dependent": "what this does depends on what the player sees along the way",
+		"command.too_long":         "this does too many things to show one by one",
+
+		// The game
+		"core.invalid_state":         "invalid game state",
+		"core.invalid_action":        "action would cause the state to be invalid",
+		"core.hit_wall":              "you hit a tree",
+		"core.out_of_bounds":         "you can't leave the forest this way",
+		"core.step_limit":            "you took more steps than allowed",
+		"core.step_limit_count":      "you took more steps than allowed: %[1]d of %[2]d",
+		"core.incomplete_path":       "the program ended before getting out of the forest",
+		"core.missing_marks":         "not enough cells were marked",
+		"core.missing_marks_count":   "not enough cells were marked: %[1]d of %[2]d",
+		"core.too_many_blocks":       "the program has more blocks than allowed",
+		"core.too_many_blocks_count": "the program has more blocks than allowed: %[1]d of %[2]d",
+		"core.rating":                "%[1]s %[2]d blocks",
+		"core.rating_par":            "%[1]s %[2]d blocks (par %[3]d)",
+
+		// The terminal interface
+		"tui.escaped":    "You made it out of the forest! %[1]s",
+		"tui.inside":     "Inside: %[1]s",
+		"tui.unplayable": "Alas, this level can't be played: %[1]s",
+	},
+}
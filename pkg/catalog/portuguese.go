@@ -0,0 +1,57 @@
+package catalog
+
+// Portuguese is the original language of the game.
+var Portuguese = &Catalog{
+	Tag: "pt-BR",
+	messages: map[Key]string{
+		// Mistakes in player programs
+		"command.stray_brace":        "este `}` não fecha nenhum bloco",
+		"command.undefined_function": "não existe nenhuma `%[1]s` chamada `%[2]s`",
+		"command.else_without_if":    "`%[1]s` precisa vir logo depois do bloco de um `%[2]s`",
+		"command.lonely_number":      "um número sozinho não faz nada, você quis dizer `%[1]s %[2]s { ... }`?",
+		"command.illegal":            "eu não sei o que `%[1]s` quer dizer",
+		"command.unexpected":         "eu não sei o que fazer com `%[1]s` aqui",
+		"command.turn_side":          "`%[1]s` precisa saber para que lado virar: `%[1]s ←` ou `%[1]s →`",
+		"command.repeat_count":       "`%[1]s` precisa de um número dizendo quantas vezes, como `%[1]s 3 { → }`",
+		"command.repeat_too_many":    "são vezes demais! Tente um número até %[1]d",
+		"command.condition_missing":  "`%[1]s` precisa de algo para verificar, como `%[1]s %[2]s { ... }`",
+		"command.unknown_sensor":     "eu não sei verificar `%[1]s`",
+		"command.nested_function":    "uma `%[1]s` precisa ser definida fora de qualquer outro bloco",
+		"command.function_name":      "`%[1]s` precisa de um nome, como `%[1]s virar_e_andar { %[2]s → %[3]s }`",
+		"command.name_is_sensor":     "`%[1]s` já é usado para verificar os arredores, escolha outro nome",
+		"command.duplicate_function": "já existe uma `%[1]s` chamada `%[2]s` na linha %[3]d",
+		"command.block_open":         "eu esperava um `{` começando o bloco de `%[1]s` aqui",
+		"command.block_unclosed":     "este bloco nunca foi fechado, está faltando um `}`",
+		"command.not_learned":        "você ainda não aprendeu `%[1]s`",
+		"command.too_many_blocks":    "seu programa usa %[1]d blocos, mas esta fase só permite %[2]d",
+		"command.blocks_too_deep":    "seus blocos estão %[1]d níveis um dentro do outro, mas esta fase só permite %[2]d",
+		"command.program_too_long":   "este programa é longo demais, tente usar repetições ou funções",
+
+		// Running player programs
+		"command.infinite_loop":    "esta repetição nunca pararia",
+		"command.out_of_budget":    "o programa rodou por tempo demais, esta repetição talvez nunca pare",
+		"command.too_deep":         "as funções chamaram umas às outras vezes demais",
+		"command.sensor_dependent": "o que isto faz depende do que se vê pelo caminho",
+		"command.too_long":         "isto faz coisas demais para mostrar uma a uma",
+
+		// The game
+		"core.invalid_state":         "o jogo ficou em um estado inválido",
+		"core.invalid_action":        "esta ação deixaria o jogo em um estado inválido",
+		"core.hit_wall":              "você bateu em uma árvore",
+		"core.out_of_bounds":         "não dá para sair da floresta por aqui",
+		"core.step_limit":            "você deu mais passos do que o permitido",
+		"core.step_limit_count":      "você deu mais passos do que o permitido: %[1]d de %[2]d",
+		"core.incomplete_path":       "o programa terminou antes de sair da floresta",
+		"core.missing_marks":         "faltou marcar algumas casas",
+		"core.missing_marks_count":   "faltou marcar algumas casas: %[1]d de %[2]d",
+		"core.too_many_blocks":       "o programa tem mais blocos do que o permitido",
+		"core.too_many_blocks_count": "o programa tem mais blocos do que o permitido: %[1]d de %[2]d",
+		"core.rating":                "%[1]s %[2]d blocos",
+		"core.rating_par":            "%[1]s %[2]d blocos (meta %[3]d)",
+
+		// The terminal interface
+		"tui.escaped":    "Você saiu da floresta! %[1]s",
+		"tui.inside":     "Dentro de: %[1]s",
+		"tui.unplayable": "Infelizmente, esta fase não pode ser jogada: %[1]s",
+	},
+}
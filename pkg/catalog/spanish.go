@@ -0,0 +1,57 @@
+package catalog
+
+// Spanish is the language for classrooms taught in Spanish.
+var Spanish = &Catalog{
+	Tag: "es",
+	messages: map[Key]string{
+		// Mistakes in player programs
+		"command.stray_brace":        "esta `}` no cierra ningún bloque",
+		"command.undefined_function": "no hay ninguna `%[1]s` llamada `%[2]s`",
+		"command.else_without_if":    "`%[1]s` tiene que ir justo después del bloque de un `%[2]s`",
+		"command.lonely_number":      "un número solo no hace nada, ¿quisiste decir `%[1]s %[2]s { ... }`?",
+		"command.illegal":            "no sé qué significa `%[1]s`",
+		"command.unexpected":         "no sé qué hacer con `%[1]s` aquí",
+		"command.turn_side":          "`%[1]s` necesita saber hacia qué lado girar: `%[1]s ←` o `%[1]s →`",
+		"command.repeat_count":       "`%[1]s` necesita un número que diga cuántas veces, como `%[1]s 3 { → }`",
+		"command.repeat_too_many":    "¡son demasiadas veces! Prueba un número hasta %[1]d",
+		"command.condition_missing":  "`%[1]s` necesita algo que comprobar, como `%[1]s %[2]s { ... }`",
+		"command.unknown_sensor":     "no sé cómo comprobar `%[1]s`",
+		"command.nested_function":    "una `%[1]s` tiene que definirse fuera de cualquier otro bloque",
+		"command.function_name":      "`%[1]s` necesita un nombre, como `%[1]s girar_y_avanzar { %[2]s → %[3]s }`",
+		"command.name_is_sensor":     "`%[1]s` ya se usa para mirar alrededor, elige otro nombre",
+		"command.duplicate_function": "ya hay una `%[1]s` llamada `%[2]s` en la línea %[3]d",
+		"command.block_open":         "esperaba una `{` empezando el bloque de `%[1]s` aquí",
+		"command.block_unclosed":     "este bloque nunca se cerró, falta una `}`",
+		"command.not_learned":        "todavía no aprendiste `%[1]s`",
+		"command.too_many_blocks":    "tu programa usa %[1]d bloques, pero este nivel solo permite %[2]d",
+		"command.blocks_too_deep":    "tus bloques están %[1]d niveles uno dentro de otro, pero este nivel solo permite %[2]d",
+		"command.program_too_long":   "este programa es demasiado largo, intenta usar repeticiones o funciones",
+
+		// Running player programs
+		"command.infinite_loop":    "esta repetición nunca terminaría",
+		"command.out_of_budget":    "el programa corrió demasiado tiempo, esta repetición quizá nunca termine",
+		"command.too_deep":         "las funciones se llamaron unas a otras demasiadas veces",
+		"command.sensor_dependent": "lo que esto hace depende de lo que se ve por el camino",
+		"command.too_long":         "esto hace demasiadas cosas para mostrarlas una a una",
+
+		// The game
+		"core.invalid_state":         "el juego quedó en un estado inválido",
+		"core.invalid_action":        "esta acción dejaría el juego en un estado inválido",
+		"core.hit_wall":              "chocaste con un árbol",
+		"core.out_of_bounds":         "no se puede salir del bosque por aquí",
+		"core.step_limit":            "diste más pasos de los permitidos",
+		"core.step_limit_count":      "diste más pasos de los permitidos: %[1]d de %[2]d",
+		"core.incomplete_path":       "el programa terminó antes de salir del bosque",
+		"core.missing_marks":         "faltó marcar algunas casillas",
+		"core.missing_marks_count":   "faltó marcar algunas casillas: %[1]d de %[2]d",
+		"core.too_many_blocks":       "el programa tiene más bloques de los permitidos",
+		"core.too_many_blocks_count": "el programa tiene más bloques de los permitidos: %[1]d de %[2]d",
+		"core.rating":                "%[1]s %[2]d bloques",
+		"core.rating_par":            "%[1]s %[2]d bloques (meta %[3]d)",
+
+		// The terminal interface
+		"tui.escaped":    "¡Saliste del bosque! %[1]s",
+		"tui.inside":     "Dentro de: %[1]s",
+		"tui.unplayable": "Lo siento, este nivel no se puede jugar: %[1]s",
+	},
+}
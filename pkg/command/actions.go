@@ -1,5 +1,51 @@
 package command
 
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownAction indicates an action that no Handler knows how to perform
+var ErrUnknownAction = errors.New("unknown action")
+
+// Action is something the player does in the maze.
+// The interface is sealed, so every action is declared in this package
+// and is performed through a Handler, which must implement all of them.
+type Action interface {
+	// Cost is how many steps performing the action counts towards the level's step limit
+	Cost() uint16
+
+	// String is how the action is written in programs
+	String() string
+
+	isAction()
+}
+
+// Handler performs each kind of Action over a state of type S.
+// Adding a new action adds a method here, so every handler fails to compile until it handles it.
+type Handler[S any] interface {
+	Walk(state S, action Walk) (S, error)
+	Turn(state S, action Turn) (S, error)
+	Forward(state S, action Forward) (S, error)
+	Mark(state S, action Mark) (S, error)
+}
+
+// Apply performs action over state through the matching method of h.
+func Apply[S any](h Handler[S], state S, action Action) (S, error) {
+	switch a := action.(type) {
+	case Walk:
+		return h.Walk(state, a)
+	case Turn:
+		return h.Turn(state, a)
+	case Forward:
+		return h.Forward(state, a)
+	case Mark:
+		return h.Mark(state, a)
+	default:
+		return state, fmt.Errorf("%w: %T", ErrUnknownAction, action)
+	}
+}
+
 // Direction represents the four cardinal directions for movement
 type Direction uint8
 
@@ -10,12 +56,79 @@ const (
 	West
 )
 
+// Rotation represents which way to turn, relative to the current heading
+type Rotation uint8
+
+const (
+	Left Rotation = iota
+	Right
+)
+
+// rotations maps each direction to where it ends up after turning [Left, Right]
+var rotations = [...][2]Direction{
+	North: {West, East},
+	South: {East, West},
+	East:  {North, South},
+	West:  {South, North},
+}
+
+// directionArrows are how each direction is written in programs
+var directionArrows = [...]string{
+	North: "↑",
+	South: "↓",
+	East:  "→",
+	West:  "←",
+}
+
+func (d Direction) String() string {
+	if int(d) < len(directionArrows) {
+		return directionArrows[d]
+	}
+	return fmt.Sprintf("Direction(%d)", d)
+}
+
+// Turn returns the direction faced after rotating from d.
+func (d Direction) Turn(rot Rotation) Direction {
+	return rotations[d][rot]
+}
+
+// Walk moves one cell in an absolute direction, regardless of the heading.
 type Walk struct {
 	Dir Direction
 }
 
+// Turn rotates the heading without moving.
+type Turn struct {
+	Rot Rotation
+}
+
+// Forward moves one cell towards the current heading.
+type Forward struct{}
+
 type Mark struct {
 	// NOTE: At this stage of development mark is intentionally empty.
 	// it can bear more data (i.e. color/rune) which might be a resource later,
 	// but as of now it is good as it is.
 }
+
+// Actions are printed the way they are written in programs, so an unfolded program can be read back.
+func (w Walk) String() string  { return w.Dir.String() }
+func (Forward) String() string { return "andar" }
+func (Mark) String() string    { return "marcar" }
+func (t Turn) String() string {
+	if t.Rot == Left {
+		return "virar " + West.String()
+	}
+	return "virar " + East.String()
+}
+
+// Walking takes a step, while turning and marking are done in place.
+func (Walk) Cost() uint16    { return 1 }
+func (Forward) Cost() uint16 { return 1 }
+func (Turn) Cost() uint16    { return 0 }
+func (Mark) Cost() uint16    { return 0 }
+
+func (Walk) isAction()    {}
+func (Forward) isAction() {}
+func (Turn) isAction()    {}
+func (Mark) isAction()    {}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"errors"
+	"testing"
+)
+
+// recorder is a Handler keeping track of the actions it was asked to perform
+type recorder struct{}
+
+func (recorder) Walk(state []Action, action Walk) ([]Action, error) {
+	return append(state, action), nil
+}
+func (recorder) Turn(state []Action, action Turn) ([]Action, error) {
+	return append(state, action), nil
+}
+func (recorder) Forward(state []Action, action Forward) ([]Action, error) {
+	return append(state, action), nil
+}
+func (recorder) Mark(state []Action, action Mark) ([]Action, error) {
+	return append(state, action), nil
+}
+
+func TestApply(t *testing.T) {
+	actions := []Action{Walk{Dir: East}, Turn{Rot: Left}, Forward{}, Mark{}}
+
+	var performed []Action
+	for _, action := range actions {
+		var err error
+		performed, err = Apply[[]Action](recorder{}, performed, action)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for ix, action := range actions {
+		if performed[ix] != action {
+			t.Errorf("expected %#v to be dispatched, got %#v", action, performed[ix])
+		}
+	}
+
+	if _, err := Apply[[]Action](recorder{}, nil, nil); !errors.Is(err, ErrUnknownAction) {
+		t.Errorf("expected %v, got %v", ErrUnknownAction, err)
+	}
+}
@@ -0,0 +1,134 @@
+package command
+
+// Span is the region of the source a node was parsed from, used to point at it
+// when reporting errors or highlighting what is running.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Node is any element of a parsed program.
+type Node interface {
+	Span() Span
+}
+
+// Stmt is a node that can appear inside a block.
+type Stmt interface {
+	Node
+	stmt()
+}
+
+// Program is the root of a parsed player program.
+type Program struct {
+	Body *Block
+
+	// Dialect is what the program was written in
+	Dialect *Dialect
+
+	// Functions are the functions defined by the player, in the order they were defined
+	Functions []*Function
+}
+
+func (p *Program) Span() Span { return p.Body.Src }
+
+// Block is a sequence of statements, either the whole program or what is between `{` and `}`.
+type Block struct {
+	Stmts []Stmt
+	Src   Span
+}
+
+func (b *Block) Span() Span { return b.Src }
+
+// Do is a leaf of the tree, performing a single action (such as Walk or Mark).
+type Do struct {
+	Action Action
+	Src    Span
+}
+
+// Repeat runs its body a fixed number of times: `repetir 3 { ... }`
+type Repeat struct {
+	Count int
+	Body  *Block
+	Src   Span
+}
+
+// If runs Then when the condition holds, or Else (which may be nil) otherwise:
+// `se parede { ... } senão { ... }`
+type If struct {
+	Cond Condition
+	Then *Block
+	Else *Block
+	Src  Span
+}
+
+// While runs its body for as long as the condition holds: `enquanto não fim { ... }`
+type While struct {
+	Cond Condition
+	Body *Block
+	Src  Span
+}
+
+// Function is a named block defined by the player, which can be called from anywhere:
+// `função virar_e_andar { virar → andar }`
+type Function struct {
+	Name string
+	Body *Block
+	Src  Span
+}
+
+func (f *Function) Span() Span { return f.Src }
+
+// Call runs the body of a function: `virar_e_andar`
+type Call struct {
+	Name string
+
+	// Target is the function being called, resolved by the parser
+	Target *Function
+
+	Src Span
+}
+
+func (d *Do) Span() Span     { return d.Src }
+func (r *Repeat) Span() Span { return r.Src }
+func (i *If) Span() Span     { return i.Src }
+func (w *While) Span() Span  { return w.Src }
+func (c *Call) Span() Span   { return c.Src }
+
+func (*Do) stmt()     {}
+func (*Repeat) stmt() {}
+func (*If) stmt()     {}
+func (*While) stmt()  {}
+func (*Call) stmt()   {}
+
+// Sensor identifies what a condition checks.
+type Sensor uint8
+
+const (
+	SensorWallAhead Sensor = iota
+	SensorWallLeft
+	SensorWallRight
+	SensorWall
+	SensorMarked
+	SensorFinish
+)
+
+// Condition is what `se` and `enquanto` check, answered by the Sensors while the program runs.
+type Condition struct {
+	Sensor Sensor
+
+	// Dir is the direction checked by SensorWall
+	Dir Direction
+
+	// Negated inverts the answer of the sensor: `não parede`
+	Negated bool
+
+	Src Span
+}
+
+func (c Condition) Span() Span { return c.Src }
+
+// String is how the condition is written in programs of the default dialect, such as `não parede ↓`.
+func (c Condition) String() string {
+	f := formatter{dialect: Dialects[0]}
+	return f.condition(c)
+}
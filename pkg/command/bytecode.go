@@ -0,0 +1,269 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Op is an operation of the instruction set player programs are compiled to.
+type Op uint8
+
+const (
+	// OpHalt ends the program
+	OpHalt Op = iota
+
+	// OpWalk walks towards the Direction in Arg
+	OpWalk
+
+	// OpForward walks towards the heading
+	OpForward
+
+	// OpTurn turns to the Rotation in Arg
+	OpTurn
+
+	// OpMark toggles the mark on the current cell
+	OpMark
+
+	// OpJump continues at Addr
+	OpJump
+
+	// OpJumpUnless continues at Addr when the condition encoded in Arg doesn't hold
+	OpJumpUnless
+
+	// OpCall runs the function starting at Addr, continuing after the call once it returns
+	OpCall
+
+	// OpReturn continues after the last call
+	OpReturn
+
+	// OpRepeat starts a loop that runs its body Addr times
+	OpRepeat
+
+	// OpNext goes back to the body starting at Addr while the innermost loop has passes left, or ends it
+	OpNext
+
+	// OpWhile starts a loop that runs for as long as its condition holds
+	OpWhile
+
+	// OpSeen remembers the world as seen by the innermost loop, failing if it was seen before
+	OpSeen
+
+	// OpEnd ends the innermost loop
+	OpEnd
+)
+
+var opNames = [...]string{
+	OpHalt:       "HALT",
+	OpWalk:       "WALK",
+	OpForward:    "FORWARD",
+	OpTurn:       "TURN",
+	OpMark:       "MARK",
+	OpJump:       "JMP",
+	OpJumpUnless: "JUNLESS",
+	OpCall:       "CALL",
+	OpReturn:     "RET",
+	OpRepeat:     "REPEAT",
+	OpNext:       "NEXT",
+	OpWhile:      "WHILE",
+	OpSeen:       "SEEN",
+	OpEnd:        "END",
+}
+
+func (op Op) String() string {
+	if int(op) < len(opNames) {
+		return opNames[op]
+	}
+	return fmt.Sprintf("Op(%d)", op)
+}
+
+// Instr is a single instruction, which fits in 4 bytes.
+type Instr struct {
+	Op Op
+
+	// Arg is a Direction, a Rotation or a condition, depending on Op
+	Arg uint8
+
+	// Addr is an instruction address or a loop count, depending on Op
+	Addr uint16
+}
+
+// Code is a compiled program.
+type Code struct {
+	Instrs []Instr
+
+	// Source maps each instruction to the node it was compiled from, for highlighting and error reporting
+	Source []Node
+
+	// Src covers the whole program
+	Src Span
+}
+
+// Compile turns a parsed program into Code for the VM.
+// The main program comes first, ending with OpHalt, followed by each function ending with OpReturn.
+// Returns a *ParseError when the program is too large to be addressed.
+func Compile(program *Program) (*Code, error) {
+	c := &compiler{code: &Code{Src: program.Span()}, entries: map[*Function]int{}}
+
+	c.block(program.Body)
+	c.emit(Instr{Op: OpHalt}, program)
+
+	for _, fn := range program.Functions {
+		c.entries[fn] = len(c.code.Instrs)
+		c.block(fn.Body)
+		c.emit(Instr{Op: OpReturn}, fn)
+	}
+
+	if len(c.code.Instrs) > math.MaxUint16 {
+		return nil, dialectOf(program).errorf(program.Span(), msgProgramTooLong)
+	}
+
+	// Functions may be called before they are compiled, so calls are patched at the end
+	for _, ix := range c.calls {
+		call := c.code.Source[ix].(*Call)
+		c.code.Instrs[ix].Addr = uint16(c.entries[call.Target])
+	}
+
+	return c.code, nil
+}
+
+// compiler emits instructions, remembering calls to be patched once every function has an address.
+type compiler struct {
+	code    *Code
+	entries map[*Function]int
+	calls   []int
+}
+
+// emit appends an instruction, returning its address.
+func (c *compiler) emit(instr Instr, node Node) int {
+	c.code.Instrs = append(c.code.Instrs, instr)
+	c.code.Source = append(c.code.Source, node)
+	return len(c.code.Instrs) - 1
+}
+
+// here is the address of the next instruction.
+// Addresses beyond math.MaxUint16 are truncated, making Compile fail afterwards.
+func (c *compiler) here() uint16 {
+	return uint16(len(c.code.Instrs))
+}
+
+// patch points the instruction at addr to the next instruction.
+func (c *compiler) patch(addr int) {
+	c.code.Instrs[addr].Addr = c.here()
+}
+
+func (c *compiler) block(b *Block) {
+	if b == nil {
+		return
+	}
+
+	for _, stmt := range b.Stmts {
+		switch s := stmt.(type) {
+		case *Do:
+			c.action(s)
+		case *Repeat:
+			if s.Count == 0 {
+				continue
+			}
+			c.emit(Instr{Op: OpRepeat, Addr: uint16(s.Count)}, s)
+			body := c.here()
+			c.block(s.Body)
+			c.emit(Instr{Op: OpNext, Addr: body}, s)
+		case *If:
+			skip := c.emit(Instr{Op: OpJumpUnless, Arg: encodeCondition(s.Cond)}, s)
+			c.block(s.Then)
+			if s.Else != nil {
+				end := c.emit(Instr{Op: OpJump}, s)
+				c.patch(skip)
+				c.block(s.Else)
+				c.patch(end)
+			} else {
+				c.patch(skip)
+			}
+		case *While:
+			c.emit(Instr{Op: OpWhile}, s)
+			head := c.here()
+			exit := c.emit(Instr{Op: OpJumpUnless, Arg: encodeCondition(s.Cond)}, s)
+			c.emit(Instr{Op: OpSeen}, s)
+			c.block(s.Body)
+			c.emit(Instr{Op: OpJump, Addr: head}, s)
+			c.patch(exit)
+			c.emit(Instr{Op: OpEnd}, s)
+		case *Call:
+			c.calls = append(c.calls, c.emit(Instr{Op: OpCall}, s))
+		}
+	}
+}
+
+func (c *compiler) action(do *Do) {
+	switch a := do.Action.(type) {
+	case Walk:
+		c.emit(Instr{Op: OpWalk, Arg: uint8(a.Dir)}, do)
+	case Forward:
+		c.emit(Instr{Op: OpForward}, do)
+	case Turn:
+		c.emit(Instr{Op: OpTurn, Arg: uint8(a.Rot)}, do)
+	case Mark:
+		c.emit(Instr{Op: OpMark}, do)
+	}
+}
+
+// Conditions are packed in a byte: the sensor in the lowest 3 bits, followed by 2 bits of direction and the negation.
+const (
+	condSensorMask = 0b111
+	condDirShift   = 3
+	condDirMask    = 0b11
+	condNegated    = 1 << 5
+)
+
+func encodeCondition(c Condition) uint8 {
+	arg := uint8(c.Sensor)&condSensorMask | (uint8(c.Dir)&condDirMask)<<condDirShift
+	if c.Negated {
+		arg |= condNegated
+	}
+	return arg
+}
+
+func decodeCondition(arg uint8) Condition {
+	return Condition{
+		Sensor:  Sensor(arg & condSensorMask),
+		Dir:     Direction(arg >> condDirShift & condDirMask),
+		Negated: arg&condNegated != 0,
+	}
+}
+
+// Disassemble writes code in a human readable form, one instruction per line, for debugging:
+//
+//	0000  REPEAT   3          ; 1:1
+//	0001  WALK     →          ; 1:13
+//	0002  NEXT     0001       ; 1:1
+func (c *Code) Disassemble(w io.Writer) error {
+	for addr, instr := range c.Instrs {
+		var operand string
+		switch instr.Op {
+		case OpWalk:
+			operand = Direction(instr.Arg).String()
+		case OpTurn:
+			operand = Turn{Rot: Rotation(instr.Arg)}.String()
+		case OpJump, OpCall, OpNext:
+			operand = fmt.Sprintf("%04d", instr.Addr)
+		case OpJumpUnless:
+			operand = fmt.Sprintf("%04d %s", instr.Addr, decodeCondition(instr.Arg))
+		case OpRepeat:
+			operand = fmt.Sprintf("%d", instr.Addr)
+		}
+
+		pos := c.Source[addr].Span().Start
+		if _, err := fmt.Fprintf(w, "%04d  %-8s %-16s ; %d:%d\n", addr, instr.Op, operand, pos.Line, pos.Column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Code) String() string {
+	var b strings.Builder
+	_ = c.Disassemble(&b)
+	return b.String()
+}
@@ -0,0 +1,262 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hkupty/mirkwood/pkg/catalog"
+)
+
+// ErrUnknownDialect indicates a dialect tag that doesn't match any of the Dialects
+var ErrUnknownDialect = errors.New("unknown dialect")
+
+// Dialect is the set of words the player language is written with, along with the language its errors are reported in.
+// Arrows, numbers and braces are the same in every dialect, only keywords and sensors change.
+type Dialect struct {
+	// Tag identifies the dialect, as a language tag such as "pt-BR"
+	Tag string
+
+	// keywords spells each keyword. The first spelling is the canonical one and the others,
+	// such as spellings without accents, are also accepted.
+	keywords map[TokenKind][]string
+
+	// sensors spells each sensor that can be checked by conditions, in the same way as keywords.
+	// SensorWall is spelled as SensorWallAhead, followed by an arrow.
+	sensors map[Sensor][]string
+
+	// catalog writes the errors reported to the player
+	catalog *catalog.Catalog
+
+	// words and sensorWords look up what each spelling means
+	words       map[string]TokenKind
+	sensorWords map[string]Sensor
+}
+
+func newDialect(tag string, keywords map[TokenKind][]string, sensors map[Sensor][]string, messages *catalog.Catalog) *Dialect {
+	d := &Dialect{
+		Tag:         tag,
+		keywords:    keywords,
+		sensors:     sensors,
+		catalog:     messages,
+		words:       map[string]TokenKind{},
+		sensorWords: map[string]Sensor{},
+	}
+
+	for kind, spellings := range keywords {
+		for _, word := range spellings {
+			d.words[word] = kind
+		}
+	}
+	for sensor, spellings := range sensors {
+		for _, word := range spellings {
+			d.sensorWords[word] = sensor
+		}
+	}
+
+	return d
+}
+
+// Dialects lists every dialect, the first one being the default.
+var Dialects = []*Dialect{Portuguese, English, Spanish}
+
+// DialectFor returns the dialect with the given tag.
+func DialectFor(tag string) (*Dialect, error) {
+	for _, d := range Dialects {
+		if d.Tag == tag {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownDialect, tag)
+}
+
+// Parse turns player code written in d into a Program.
+// Returns a *ParseError for the first mistake found.
+func (d *Dialect) Parse(src string) (*Program, error) {
+	return parse(src, d)
+}
+
+// Tokenize splits src, written in d, into tokens, ending with a TokEOF token.
+func (d *Dialect) Tokenize(src string) []Token {
+	lexer := NewLexer(src, d)
+	var tokens []Token
+	for {
+		tok := lexer.Next()
+		tokens = append(tokens, tok)
+		if tok.Kind == TokEOF {
+			return tokens
+		}
+	}
+}
+
+// dialectOf returns the dialect program was written in, which is the default one for programs built by hand.
+func dialectOf(program *Program) *Dialect {
+	if program.Dialect == nil {
+		return Dialects[0]
+	}
+	return program.Dialect
+}
+
+// keyword spells kind, preferring a spelling without accents when ascii is set.
+func (d *Dialect) keyword(kind TokenKind, ascii bool) string {
+	return spell(d.keywords[kind], ascii)
+}
+
+// sensor spells the sensor checked by c, without its direction nor negation.
+func (d *Dialect) sensor(c Condition, ascii bool) string {
+	if c.Sensor == SensorWall {
+		return spell(d.sensors[SensorWallAhead], ascii)
+	}
+	return spell(d.sensors[c.Sensor], ascii)
+}
+
+// reserved reports whether word has a meaning in d, so it can't be the name of a function.
+func (d *Dialect) reserved(word string) bool {
+	_, keyword := d.words[word]
+	_, sensor := d.sensorWords[word]
+	_, arrow := arrows[word]
+	return keyword || sensor || arrow
+}
+
+// feature spells the construct needed for f.
+func (d *Dialect) feature(f Feature) string {
+	if kind, ok := featureKeywords[f]; ok {
+		return d.keyword(kind, false)
+	}
+	if f == FeatureWalk {
+		return West.String()
+	}
+	return fmt.Sprintf("Feature(%#x)", uint16(f))
+}
+
+// errorf creates a ParseError pointing at src, with a message in the language of d.
+func (d *Dialect) errorf(src Span, key catalog.Key, args ...any) error {
+	return &ParseError{Src: src, Msg: d.catalog.Text(key, args...), Key: key, Args: args}
+}
+
+// spell picks the canonical spelling, or the first one made only of ASCII characters when ascii is set.
+func spell(spellings []string, ascii bool) string {
+	if !ascii {
+		return spellings[0]
+	}
+
+	for _, word := range spellings {
+		if isASCII(word) {
+			return word
+		}
+	}
+	return spellings[0]
+}
+
+func isASCII(word string) bool {
+	for ix := range len(word) {
+		if word[ix] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// featureKeywords are the keywords introducing each feature, other than arrows
+var featureKeywords = map[Feature]TokenKind{
+	FeatureTurn:     TokTurn,
+	FeatureForward:  TokForward,
+	FeatureMark:     TokMark,
+	FeatureRepeat:   TokRepeat,
+	FeatureIf:       TokIf,
+	FeatureWhile:    TokWhile,
+	FeatureFunction: TokFunction,
+}
+
+// Messages reported to the player, with the arguments each one takes listed next to it.
+const (
+	msgStrayBrace        catalog.Key = "command.stray_brace"        // none
+	msgUndefinedFunction catalog.Key = "command.undefined_function" // function keyword, name
+	msgElseWithoutIf     catalog.Key = "command.else_without_if"    // else keyword, if keyword
+	msgLonelyNumber      catalog.Key = "command.lonely_number"      // repeat keyword, number
+	msgIllegal           catalog.Key = "command.illegal"            // text
+	msgUnexpected        catalog.Key = "command.unexpected"         // text
+	msgTurnSide          catalog.Key = "command.turn_side"          // turn keyword
+	msgRepeatCount       catalog.Key = "command.repeat_count"       // repeat keyword
+	msgRepeatTooMany     catalog.Key = "command.repeat_too_many"    // maximum count
+	msgConditionMissing  catalog.Key = "command.condition_missing"  // keyword, wall sensor
+	msgUnknownSensor     catalog.Key = "command.unknown_sensor"     // text
+	msgNestedFunction    catalog.Key = "command.nested_function"    // function keyword
+	msgFunctionName      catalog.Key = "command.function_name"      // function keyword, turn keyword, forward keyword
+	msgNameIsSensor      catalog.Key = "command.name_is_sensor"     // name
+	msgDuplicateFunction catalog.Key = "command.duplicate_function" // function keyword, name, line of the first definition
+	msgBlockOpen         catalog.Key = "command.block_open"         // keyword owning the block
+	msgBlockUnclosed     catalog.Key = "command.block_unclosed"     // none
+	msgNotLearned        catalog.Key = "command.not_learned"        // feature
+	msgTooManyBlocks     catalog.Key = "command.too_many_blocks"    // blocks, maximum blocks
+	msgTooDeep           catalog.Key = "command.blocks_too_deep"    // depth, maximum depth
+	msgProgramTooLong    catalog.Key = "command.program_too_long"   // none
+)
+
+// Portuguese is the original dialect of the language.
+var Portuguese = newDialect("pt-BR",
+	map[TokenKind][]string{
+		TokRepeat:   {"repetir"},
+		TokIf:       {"se"},
+		TokElse:     {"senão", "senao"},
+		TokWhile:    {"enquanto"},
+		TokMark:     {"marcar"},
+		TokForward:  {"andar"},
+		TokTurn:     {"virar"},
+		TokNot:      {"não", "nao"},
+		TokFunction: {"função", "funcao"},
+	},
+	map[Sensor][]string{
+		SensorWallAhead: {"parede"},
+		SensorWallLeft:  {"parede_esquerda"},
+		SensorWallRight: {"parede_direita"},
+		SensorMarked:    {"marcado"},
+		SensorFinish:    {"fim"},
+	},
+	catalog.Portuguese,
+)
+
+// English is the dialect for classrooms taught in English.
+var English = newDialect("en",
+	map[TokenKind][]string{
+		TokRepeat:   {"repeat"},
+		TokIf:       {"if"},
+		TokElse:     {"else"},
+		TokWhile:    {"while"},
+		TokMark:     {"mark"},
+		TokForward:  {"forward"},
+		TokTurn:     {"turn"},
+		TokNot:      {"not"},
+		TokFunction: {"function"},
+	},
+	map[Sensor][]string{
+		SensorWallAhead: {"wall"},
+		SensorWallLeft:  {"wall_left"},
+		SensorWallRight: {"wall_right"},
+		SensorMarked:    {"marked"},
+		SensorFinish:    {"finish"},
+	},
+	catalog.English,
+)
+
+// Spanish is the dialect for classrooms taught in Spanish.
+var Spanish = newDialect("es",
+	map[TokenKind][]string{
+		TokRepeat:   {"repetir"},
+		TokIf:       {"si"},
+		TokElse:     {"sino"},
+		TokWhile:    {"mientras"},
+		TokMark:     {"marcar"},
+		TokForward:  {"avanzar"},
+		TokTurn:     {"girar"},
+		TokNot:      {"no"},
+		TokFunction: {"función", "funcion"},
+	},
+	map[Sensor][]string{
+		SensorWallAhead: {"pared"},
+		SensorWallLeft:  {"pared_izquierda"},
+		SensorWallRight: {"pared_derecha"},
+		SensorMarked:    {"marcado"},
+		SensorFinish:    {"fin"},
+	},
+	catalog.Spanish,
+)
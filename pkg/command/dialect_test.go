@@ -0,0 +1,170 @@
+package command
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hkupty/mirkwood/pkg/catalog"
+)
+
+func TestDialects(t *testing.T) {
+	sources := map[*Dialect]string{
+		Portuguese: "função f { se não parede ↓ { andar } senão { virar ← } }\nenquanto não fim { f marcar }",
+		English:    "function f { if not wall ↓ { forward } else { turn ← } }\nwhile not finish { f mark }",
+		Spanish:    "funcion f { si no pared ↓ { avanzar } sino { girar ← } }\nmientras no fin { f marcar }",
+	}
+
+	expected := Format(mustParse(t, Portuguese, sources[Portuguese]), FormatOptions{Dialect: Portuguese})
+
+	for dialect, src := range sources {
+		t.Run(dialect.Tag, func(t *testing.T) {
+			program := mustParse(t, dialect, src)
+			if program.Dialect != dialect {
+				t.Fatalf("expected the program to be in %s, got %s", dialect.Tag, program.Dialect.Tag)
+			}
+
+			// Every dialect writes the same program
+			if translated := Format(program, FormatOptions{Dialect: Portuguese}); translated != expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", expected, translated)
+			}
+		})
+	}
+}
+
+func TestDialectErrors(t *testing.T) {
+	cases := []struct {
+		dialect  *Dialect
+		src      string
+		expected string
+	}{
+		{Portuguese, "repetir { → }", "`repetir` precisa de um número"},
+		{English, "repeat { → }", "`repeat` needs a number"},
+		{Spanish, "repetir { → }", "`repetir` necesita un número"},
+		{English, "3", "did you mean `repeat 3 { ... }`?"},
+		{Spanish, "si { → }", "como `si pared { ... }`"},
+		{English, "f", "there is no `function` called `f`"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.dialect.Tag+" "+tc.src, func(t *testing.T) {
+			_, err := tc.dialect.Parse(tc.src)
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if !strings.Contains(parseErr.Msg, tc.expected) {
+				t.Fatalf("expected the error to say %q, got %q", tc.expected, parseErr.Msg)
+			}
+		})
+	}
+}
+
+func TestDialectFor(t *testing.T) {
+	for _, dialect := range Dialects {
+		if found, err := DialectFor(dialect.Tag); err != nil || found != dialect {
+			t.Errorf("expected to find %s, got %v", dialect.Tag, err)
+		}
+	}
+
+	if _, err := DialectFor("tlh"); !errors.Is(err, ErrUnknownDialect) {
+		t.Errorf("expected an unknown dialect, got %v", err)
+	}
+}
+
+func mustParse(t testing.TB, dialect *Dialect, src string) *Program {
+	program, err := dialect.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program
+}
+
+// withoutNames clears what is particular to how a program was written, so translations can be compared by structure
+func withoutNames(program *Program) *Program {
+	program = withoutSpans(program)
+	program.Dialect = nil
+	for _, fn := range program.Functions {
+		fn.Name = ""
+	}
+
+	var block func(b *Block)
+	block = func(b *Block) {
+		if b == nil {
+			return
+		}
+		for _, stmt := range b.Stmts {
+			switch s := stmt.(type) {
+			case *Call:
+				s.Name = ""
+			case *Repeat:
+				block(s.Body)
+			case *If:
+				block(s.Then)
+				block(s.Else)
+			case *While:
+				block(s.Body)
+			}
+		}
+	}
+
+	block(program.Body)
+	for _, fn := range program.Functions {
+		block(fn.Body)
+	}
+	return program
+}
+
+func FuzzTranslate(f *testing.F) {
+	f.Add("função f { se não parede ↓ { andar } senão { virar ← } } enquanto não fim { f marcar }", false)
+	f.Add("função if { → } função if_ { ← } if if_", true)
+	f.Add("função wall { repetir 2 { ↓ } } wall", false)
+	f.Fuzz(func(t *testing.T, src string, ascii bool) {
+		program, err := Parse(src)
+		if err != nil {
+			return
+		}
+
+		opts := FormatOptions{}
+		if ascii {
+			opts.Arrows = ASCIIArrows
+		}
+
+		for _, dialect := range Dialects {
+			opts.Dialect = dialect
+			translated := Format(program, opts)
+
+			reparsed, err := dialect.Parse(translated)
+			if err != nil {
+				t.Fatalf("program translated to %s doesn't parse: %v\n%s", dialect.Tag, err, translated)
+			}
+
+			// Parsing again sets the spans of the original program aside, so it is parsed anew each time
+			original, _ := Parse(src)
+			if !reflect.DeepEqual(withoutNames(original), withoutNames(reparsed)) {
+				t.Fatalf("translating to %s changed the program:\n%s", dialect.Tag, translated)
+			}
+		}
+	})
+}
+
+func TestLocalizeErrors(t *testing.T) {
+	_, err := Portuguese.Parse("repetir { → }")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	if expected := "1:9: `repetir` needs a number saying how many times, like `repetir 3 { → }`"; catalog.English.Render(err) != expected {
+		t.Fatalf("expected %q, got %q", expected, catalog.English.Render(err))
+	}
+
+	err = &RuntimeError{Src: parseErr.Src, Err: ErrOutOfBudget}
+	if !errors.Is(err, ErrInfiniteLoop) {
+		t.Fatalf("expected %v to be an infinite loop", err)
+	}
+	if expected := "1:9: o programa rodou por tempo demais, esta repetição talvez nunca pare"; catalog.Portuguese.Render(err) != expected {
+		t.Fatalf("expected %q, got %q", expected, catalog.Portuguese.Render(err))
+	}
+}
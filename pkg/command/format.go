@@ -0,0 +1,188 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ArrowStyle chooses how arrows are written when formatting programs.
+type ArrowStyle uint8
+
+const (
+	// UnicodeArrows writes the canonical arrows (← ↑ → ↓) and accented keywords
+	UnicodeArrows ArrowStyle = iota
+
+	// ASCIIArrows writes arrows and keywords that can be typed on any keyboard (<- ^ -> v)
+	ASCIIArrows
+)
+
+// FormatOptions controls how Format prints programs.
+type FormatOptions struct {
+	Arrows ArrowStyle
+
+	// Indent is used once per level of nesting, two spaces when empty
+	Indent string
+
+	// Dialect translates the program to another dialect, keeping its own when nil
+	Dialect *Dialect
+}
+
+// asciiArrows are the ASCII fallbacks of each direction, chosen among the ones the lexer accepts
+var asciiArrows = [...]string{
+	North: "^",
+	South: "v",
+	East:  "->",
+	West:  "<-",
+}
+
+// Format prints program back as source in a canonical form:
+// functions come first, followed by the rest of the program, with one statement per line
+// and blocks indented by how deep they are.
+// Parsing the result gives back the same program, apart from where each node is in the source.
+// When translating, functions named after words of the target dialect get a trailing `_`, so they can still be parsed.
+func Format(program *Program, opts FormatOptions) string {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+
+	dialect := opts.Dialect
+	if dialect == nil {
+		dialect = dialectOf(program)
+	}
+
+	f := formatter{opts: opts, dialect: dialect, ascii: opts.Arrows == ASCIIArrows}
+	f.rename(program.Functions)
+
+	for _, fn := range program.Functions {
+		f.b.WriteString(f.keyword(TokFunction) + " " + f.name(fn.Name) + " ")
+		f.block(fn.Body, 0)
+		f.b.WriteString("\n\n")
+	}
+	for _, stmt := range program.Body.Stmts {
+		f.stmt(stmt, 0)
+	}
+
+	return f.b.String()
+}
+
+// formatter accumulates the formatted source.
+type formatter struct {
+	opts    FormatOptions
+	dialect *Dialect
+	ascii   bool
+	b       strings.Builder
+
+	// names maps functions to the names they are written with
+	names map[string]string
+}
+
+// stmt writes a statement nested depth blocks deep in its own line.
+func (f *formatter) stmt(stmt Stmt, depth int) {
+	f.b.WriteString(strings.Repeat(f.opts.Indent, depth))
+
+	switch s := stmt.(type) {
+	case *Do:
+		f.b.WriteString(f.action(s.Action))
+	case *Call:
+		f.b.WriteString(f.name(s.Name))
+	case *Repeat:
+		f.b.WriteString(f.keyword(TokRepeat) + " " + strconv.Itoa(s.Count) + " ")
+		f.block(s.Body, depth)
+	case *While:
+		f.b.WriteString(f.keyword(TokWhile) + " " + f.condition(s.Cond) + " ")
+		f.block(s.Body, depth)
+	case *If:
+		f.b.WriteString(f.keyword(TokIf) + " " + f.condition(s.Cond) + " ")
+		f.block(s.Then, depth)
+		if s.Else != nil {
+			f.b.WriteString(" " + f.keyword(TokElse) + " ")
+			f.block(s.Else, depth)
+		}
+	}
+
+	f.b.WriteString("\n")
+}
+
+// block writes `{`, the statements of b one level deeper than depth and the closing `}`,
+// leaving the line open so the `else` keyword can follow it.
+func (f *formatter) block(b *Block, depth int) {
+	if len(b.Stmts) == 0 {
+		f.b.WriteString("{ }")
+		return
+	}
+
+	f.b.WriteString("{\n")
+	for _, stmt := range b.Stmts {
+		f.stmt(stmt, depth+1)
+	}
+	f.b.WriteString(strings.Repeat(f.opts.Indent, depth) + "}")
+}
+
+func (f *formatter) action(action Action) string {
+	switch a := action.(type) {
+	case Walk:
+		return f.arrow(a.Dir)
+	case Turn:
+		if a.Rot == Left {
+			return f.keyword(TokTurn) + " " + f.arrow(West)
+		}
+		return f.keyword(TokTurn) + " " + f.arrow(East)
+	case Forward:
+		return f.keyword(TokForward)
+	case Mark:
+		return f.keyword(TokMark)
+	default:
+		return action.String()
+	}
+}
+
+func (f *formatter) condition(c Condition) string {
+	text := f.dialect.sensor(c, f.ascii)
+	if c.Sensor == SensorWall {
+		text += " " + f.arrow(c.Dir)
+	}
+	if c.Negated {
+		text = f.keyword(TokNot) + " " + text
+	}
+	return text
+}
+
+// rename picks a name for each function that is not taken by a word of the dialect nor by another function.
+func (f *formatter) rename(functions []*Function) {
+	f.names = map[string]string{}
+	taken := map[string]bool{}
+	for _, fn := range functions {
+		taken[fn.Name] = true
+	}
+
+	for _, fn := range functions {
+		if !f.dialect.reserved(fn.Name) {
+			continue
+		}
+
+		name := fn.Name + "_"
+		for f.dialect.reserved(name) || taken[name] {
+			name += "_"
+		}
+		f.names[fn.Name] = name
+		taken[name] = true
+	}
+}
+
+func (f *formatter) name(name string) string {
+	if renamed, ok := f.names[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+func (f *formatter) arrow(dir Direction) string {
+	if f.ascii {
+		return asciiArrows[dir]
+	}
+	return dir.String()
+}
+
+func (f *formatter) keyword(kind TokenKind) string {
+	return f.dialect.keyword(kind, f.ascii)
+}
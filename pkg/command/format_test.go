@@ -0,0 +1,126 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	program, err := Parse("se nao parede v{->}senao{ } repetir 02 {marcar se fim { virar < }}\nfuncao vai{andar vai}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		opts     FormatOptions
+		expected string
+	}{
+		{"unicode", FormatOptions{}, "" +
+			"função vai {\n" +
+			"  andar\n" +
+			"  vai\n" +
+			"}\n" +
+			"\n" +
+			"se não parede ↓ {\n" +
+			"  →\n" +
+			"} senão { }\n" +
+			"repetir 2 {\n" +
+			"  marcar\n" +
+			"  se fim {\n" +
+			"    virar ←\n" +
+			"  }\n" +
+			"}\n"},
+		{"ascii", FormatOptions{Arrows: ASCIIArrows, Indent: "\t"}, "" +
+			"funcao vai {\n" +
+			"\tandar\n" +
+			"\tvai\n" +
+			"}\n" +
+			"\n" +
+			"se nao parede v {\n" +
+			"\t->\n" +
+			"} senao { }\n" +
+			"repetir 2 {\n" +
+			"\tmarcar\n" +
+			"\tse fim {\n" +
+			"\t\tvirar <-\n" +
+			"\t}\n" +
+			"}\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if formatted := Format(program, tc.opts); formatted != tc.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", tc.expected, formatted)
+			}
+		})
+	}
+}
+
+// withoutSpans clears where every node of program is in the source, so programs can be compared by structure
+func withoutSpans(program *Program) *Program {
+	var block func(b *Block)
+	block = func(b *Block) {
+		if b == nil {
+			return
+		}
+		b.Src = Span{}
+		for _, stmt := range b.Stmts {
+			switch s := stmt.(type) {
+			case *Do:
+				s.Src = Span{}
+			case *Call:
+				s.Src = Span{}
+			case *Repeat:
+				s.Src = Span{}
+				block(s.Body)
+			case *If:
+				s.Src, s.Cond.Src = Span{}, Span{}
+				block(s.Then)
+				block(s.Else)
+			case *While:
+				s.Src, s.Cond.Src = Span{}, Span{}
+				block(s.Body)
+			}
+		}
+	}
+
+	block(program.Body)
+	for _, fn := range program.Functions {
+		fn.Src = Span{}
+		block(fn.Body)
+	}
+	return program
+}
+
+func FuzzFormat(f *testing.F) {
+	f.Add("repetir 3 { ← }", false)
+	f.Add("se não parede ↓ { ↓ } senão { → } enquanto fim { }", true)
+	f.Add("função f { se não fim { andar f } } f", false)
+	f.Add("f função f { repetir 2 { virar -> marcar } }", true)
+	f.Fuzz(func(t *testing.T, src string, ascii bool) {
+		program, err := Parse(src)
+		if err != nil {
+			return
+		}
+
+		opts := FormatOptions{}
+		if ascii {
+			opts.Arrows = ASCIIArrows
+		}
+
+		formatted := Format(program, opts)
+		reparsed, err := Parse(formatted)
+		if err != nil {
+			t.Fatalf("formatted program doesn't parse: %v\n%s", err, formatted)
+		}
+
+		if again := Format(reparsed, opts); again != formatted {
+			t.Fatalf("formatting is not idempotent:\n%s\nbecame:\n%s", formatted, again)
+		}
+
+		if !reflect.DeepEqual(withoutSpans(program), withoutSpans(reparsed)) {
+			t.Fatalf("formatting changed the program:\n%s", formatted)
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/hkupty/mirkwood/pkg/catalog"
+)
+
+var (
+	// ErrInfiniteLoop indicates a program that would never stop
+	ErrInfiniteLoop = catalog.New("command.infinite_loop")
+
+	// ErrOutOfBudget indicates a program that ran more instructions than allowed, most likely because it never stops
+	ErrOutOfBudget = ErrInfiniteLoop.Detail("command.out_of_budget")
+
+	// ErrTooDeep indicates functions calling each other more times than allowed, usually a recursion that never ends
+	ErrTooDeep = catalog.New("command.too_deep")
+)
+
+// RuntimeError reports a problem found while running a program, pointing at the code that caused it.
+type RuntimeError struct {
+	Src Span
+	Err error
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%d:%d: %v", e.Src.Start.Line, e.Src.Start.Column, e.Err)
+}
+
+// Localize writes the error in the language of c.
+func (e *RuntimeError) Localize(c *catalog.Catalog) string {
+	return fmt.Sprintf("%d:%d: %s", e.Src.Start.Line, e.Src.Start.Column, c.Render(e.Err))
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RuntimeError) Span() Span {
+	return e.Src
+}
+
+// Limits bounds how much a program can do, so mistakes end with an error instead of hanging.
+type Limits struct {
+	// Budget is how many instructions the program may run in total (0 = unlimited)
+	Budget int
+
+	// MaxDepth is how many function calls can be running at once (0 = unlimited)
+	MaxDepth int
+}
+
+// DefaultLimits are generous enough for any solution that fits in a board.
+var DefaultLimits = Limits{
+	Budget:   100_000,
+	MaxDepth: 1024,
+}
+
+// Interpreter runs a Program one action at a time.
+// Control flow is resolved lazily, so conditions are checked against the world as it is
+// when they are reached, after every action before them was performed.
+type Interpreter struct {
+	program *Program
+	limits  Limits
+	stack   []frame
+	origin  *Do
+
+	// spent is how many instructions were run so far
+	spent int
+
+	// calls are the function calls currently running, outermost first
+	calls []*Call
+}
+
+// frame is a block being executed, along with how far into it the interpreter is.
+type frame struct {
+	block *Block
+
+	// index is the next statement to run
+	index int
+
+	// loop is the statement repeating this block (*Repeat or *While), or nil
+	loop Stmt
+
+	// call is the function call that entered this block, or nil
+	call *Call
+
+	// remaining is how many more times the block runs after the current pass, for `repetir`
+	remaining int
+
+	// seen holds the fingerprint of the world every time an `enquanto` checked its condition.
+	// Since the program can only observe the world through its sensors, seeing the same world
+	// at the same point of the program twice means it will keep going around forever.
+	seen map[any]struct{}
+}
+
+// NewInterpreter creates an interpreter positioned at the start of program.
+func NewInterpreter(program *Program, limits Limits) *Interpreter {
+	return &Interpreter{
+		program: program,
+		limits:  limits,
+		stack:   []frame{{block: program.Body}},
+	}
+}
+
+// Next runs the program until it produces an action, answering conditions with sensors.
+// The caller is expected to perform the action and pass the updated sensors in the following call.
+// Returns done once the program has finished, in which case there is no action.
+func (in *Interpreter) Next(sensors Sensors) (action Action, done bool, err error) {
+	for len(in.stack) > 0 {
+		in.spent++
+		if in.limits.Budget > 0 && in.spent > in.limits.Budget {
+			return nil, false, &RuntimeError{
+				Src: in.innermostLoop(),
+				Err: ErrOutOfBudget,
+			}
+		}
+
+		top := &in.stack[len(in.stack)-1]
+
+		if top.index >= len(top.block.Stmts) {
+			switch loop := top.loop.(type) {
+			case *Repeat:
+				if top.remaining > 0 {
+					top.remaining--
+					top.index = 0
+					continue
+				}
+			case *While:
+				if loop.Cond.Eval(sensors) {
+					if err := top.remember(sensors); err != nil {
+						return nil, false, err
+					}
+					top.index = 0
+					continue
+				}
+			}
+
+			if top.call != nil {
+				in.calls = in.calls[:len(in.calls)-1]
+			}
+			in.stack = in.stack[:len(in.stack)-1]
+			continue
+		}
+
+		stmt := top.block.Stmts[top.index]
+		top.index++
+
+		switch s := stmt.(type) {
+		case *Do:
+			in.origin = s
+			return s.Action, false, nil
+		case *Repeat:
+			if s.Count > 0 {
+				in.push(frame{block: s.Body, loop: s, remaining: s.Count - 1})
+			}
+		case *If:
+			if s.Cond.Eval(sensors) {
+				in.push(frame{block: s.Then})
+			} else if s.Else != nil {
+				in.push(frame{block: s.Else})
+			}
+		case *While:
+			if s.Cond.Eval(sensors) {
+				in.push(frame{block: s.Body, loop: s, seen: map[any]struct{}{sensors.Fingerprint(): {}}})
+			}
+		case *Call:
+			if in.limits.MaxDepth > 0 && len(in.calls) >= in.limits.MaxDepth {
+				return nil, false, &RuntimeError{Src: s.Span(), Err: ErrTooDeep}
+			}
+			in.calls = append(in.calls, s)
+			in.push(frame{block: s.Target.Body, call: s})
+		}
+	}
+
+	in.origin = nil
+	return nil, true, nil
+}
+
+// Origin returns the node that produced the last action, so it can be highlighted,
+// or nil if no action was produced yet or the program is done.
+func (in *Interpreter) Origin() *Do {
+	return in.origin
+}
+
+// CallStack returns the function calls currently running, outermost first.
+// The returned slice must not be modified.
+func (in *Interpreter) CallStack() []*Call {
+	return in.calls
+}
+
+func (in *Interpreter) push(f frame) {
+	in.stack = append(in.stack, f)
+}
+
+// innermostLoop returns the span of the innermost running loop, or the whole program if there is none.
+func (in *Interpreter) innermostLoop() Span {
+	for ix := len(in.stack) - 1; ix >= 0; ix-- {
+		if loop := in.stack[ix].loop; loop != nil {
+			return loop.Span()
+		}
+	}
+	return in.program.Span()
+}
+
+// remember records the world seen by the condition of an `enquanto`,
+// failing if it was already seen by this same loop.
+func (f *frame) remember(sensors Sensors) error {
+	fingerprint := sensors.Fingerprint()
+	if _, ok := f.seen[fingerprint]; ok {
+		return &RuntimeError{Src: f.loop.Span(), Err: ErrInfiniteLoop}
+	}
+	f.seen[fingerprint] = struct{}{}
+	return nil
+}
+
+// Eval answers the condition with the given sensors.
+func (c Condition) Eval(sensors Sensors) bool {
+	var answer bool
+	switch c.Sensor {
+	case SensorWallAhead:
+		answer = sensors.WallAhead()
+	case SensorWallLeft:
+		answer = sensors.WallLeft()
+	case SensorWallRight:
+		answer = sensors.WallRight()
+	case SensorWall:
+		answer = sensors.Wall(c.Dir)
+	case SensorMarked:
+		answer = sensors.IsMarked()
+	case SensorFinish:
+		answer = sensors.AtFinish()
+	}
+	return answer != c.Negated
+}
@@ -0,0 +1,209 @@
+package command
+
+import (
+	"errors"
+	"testing"
+)
+
+// blindSensors never senses anything, so only the shape of the program drives it
+type blindSensors struct{}
+
+func (blindSensors) WallAhead() bool         { return false }
+func (blindSensors) WallLeft() bool          { return false }
+func (blindSensors) WallRight() bool         { return false }
+func (blindSensors) Wall(dir Direction) bool { return false }
+func (blindSensors) IsMarked() bool          { return false }
+func (blindSensors) AtFinish() bool          { return false }
+func (blindSensors) Fingerprint() any        { return struct{}{} }
+
+// trace runs the program to completion, collecting the actions it produced
+func trace(t *testing.T, src string) []Action {
+	actions, err := record(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return actions
+}
+
+// record runs the program to completion within DefaultLimits, returning the actions it produced
+func record(src string) ([]Action, error) {
+	program, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []Action
+	interpreter := NewInterpreter(program, DefaultLimits)
+	for {
+		action, done, err := interpreter.Next(blindSensors{})
+		if err != nil {
+			return actions, err
+		}
+		if done {
+			return actions, nil
+		}
+		actions = append(actions, action)
+	}
+}
+
+func TestInterpreter(t *testing.T) {
+	cases := []struct {
+		src      string
+		expected []Action
+	}{
+		{"→ ↓", []Action{Walk{Dir: East}, Walk{Dir: South}}},
+		{"repetir 0 { → } ↓", []Action{Walk{Dir: South}}},
+		{"repetir 2 { → repetir 2 { ↓ } }", []Action{
+			Walk{Dir: East}, Walk{Dir: South}, Walk{Dir: South},
+			Walk{Dir: East}, Walk{Dir: South}, Walk{Dir: South},
+		}},
+		{"se parede { → } senão { ← } se marcado { marcar }", []Action{Walk{Dir: West}}},
+		{"repetir 3 { } se fim { → }", nil},
+		{"enquanto fim { → } ↓", []Action{Walk{Dir: South}}},
+		{"se não fim { → }", []Action{Walk{Dir: East}}},
+		{"f ↓ f\nfunção f { → marcar }", []Action{Walk{Dir: East}, Mark{}, Walk{Dir: South}, Walk{Dir: East}, Mark{}}},
+		{"função f { → g } função g { ↓ } repetir 2 { f }", []Action{
+			Walk{Dir: East}, Walk{Dir: South}, Walk{Dir: East}, Walk{Dir: South},
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.src, func(t *testing.T) {
+			actions := trace(t, tc.src)
+			if len(actions) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, actions)
+			}
+			for ix := range actions {
+				if actions[ix] != tc.expected[ix] {
+					t.Fatalf("expected %v, got %v", tc.expected, actions)
+				}
+			}
+		})
+	}
+}
+
+func TestInterpreterOrigin(t *testing.T) {
+	program, err := Parse("repetir 2 {\n  →\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interpreter := NewInterpreter(program, DefaultLimits)
+	for range 2 {
+		if _, _, err := interpreter.Next(blindSensors{}); err != nil {
+			t.Fatal(err)
+		}
+
+		origin := interpreter.Origin()
+		if origin == nil || origin.Src.Start.Line != 2 || origin.Src.Start.Column != 3 {
+			t.Fatalf("expected the arrow at 2:3 to be running, got %+v", origin)
+		}
+	}
+
+	if _, done, _ := interpreter.Next(blindSensors{}); !done || interpreter.Origin() != nil {
+		t.Fatal("expected the program to be done")
+	}
+}
+
+func TestInterpreterInfiniteLoop(t *testing.T) {
+	cases := []struct {
+		name   string
+		src    string
+		limits Limits
+		line   int
+		column int
+	}{
+		// Blind sensors never change, so the second check of the condition sees the same world
+		{"enquanto cycle", "↓\nenquanto não fim {\n  →\n}", DefaultLimits, 2, 1},
+		{"budget in nested repeat", "repetir 9 {\n  repetir 9 { → }\n}", Limits{Budget: 10}, 2, 3},
+		{"budget without loops", "→ → → →", Limits{Budget: 2}, 1, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := Parse(tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			interpreter := NewInterpreter(program, tc.limits)
+			for {
+				_, done, err := interpreter.Next(blindSensors{})
+				if done {
+					t.Fatal("expected the program to be stopped")
+				}
+				if err == nil {
+					continue
+				}
+
+				if !errors.Is(err, ErrInfiniteLoop) {
+					t.Fatalf("expected an infinite loop, got %v", err)
+				}
+
+				var runtimeErr *RuntimeError
+				if !errors.As(err, &runtimeErr) {
+					t.Fatalf("expected a RuntimeError, got %v", err)
+				}
+				if span := runtimeErr.Span(); span.Start.Line != tc.line || span.Start.Column != tc.column {
+					t.Fatalf("expected error at %d:%d, got %v", tc.line, tc.column, err)
+				}
+				return
+			}
+		})
+	}
+}
+
+func TestInterpreterCallStack(t *testing.T) {
+	program, err := Parse("função f { g }\nfunção g { → }\nf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interpreter := NewInterpreter(program, DefaultLimits)
+	if _, _, err := interpreter.Next(blindSensors{}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := interpreter.CallStack()
+	if len(calls) != 2 || calls[0].Name != "f" || calls[1].Name != "g" {
+		t.Fatalf("expected to be inside f and g, got %v", calls)
+	}
+	if calls[0].Src.Start.Line != 3 || calls[1].Src.Start.Line != 1 {
+		t.Fatalf("calls point to the wrong place: %+v %+v", calls[0].Src, calls[1].Src)
+	}
+
+	if _, done, _ := interpreter.Next(blindSensors{}); !done || len(interpreter.CallStack()) != 0 {
+		t.Fatal("expected the program to be done and every call to have returned")
+	}
+}
+
+func TestInterpreterTooDeep(t *testing.T) {
+	program, err := Parse("função f {\n  → f\n}\nf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interpreter := NewInterpreter(program, Limits{MaxDepth: 5})
+	for steps := 0; ; steps++ {
+		_, done, err := interpreter.Next(blindSensors{})
+		if done {
+			t.Fatal("expected the recursion to be stopped")
+		}
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, ErrTooDeep) {
+			t.Fatalf("expected too deep, got %v", err)
+		}
+		if steps != 5 {
+			t.Errorf("expected 5 calls to walk before stopping, got %d", steps)
+		}
+
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) || runtimeErr.Span().Start.Line != 2 || runtimeErr.Span().Start.Column != 5 {
+			t.Fatalf("expected the recursive call at 2:5 to be blamed, got %v", err)
+		}
+		return
+	}
+}
@@ -0,0 +1,213 @@
+package command
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind classifies the tokens of the player language
+type TokenKind uint8
+
+const (
+	TokEOF TokenKind = iota
+	TokIllegal
+	TokArrow
+	TokInt
+	TokLBrace
+	TokRBrace
+	TokIdent
+	TokRepeat
+	TokIf
+	TokElse
+	TokWhile
+	TokMark
+	TokForward
+	TokTurn
+	TokNot
+	TokFunction
+)
+
+var tokenNames = [...]string{
+	TokEOF:      "end of program",
+	TokIllegal:  "illegal character",
+	TokArrow:    "arrow",
+	TokInt:      "number",
+	TokLBrace:   "{",
+	TokRBrace:   "}",
+	TokIdent:    "name",
+	TokRepeat:   "repetir",
+	TokIf:       "se",
+	TokElse:     "senão",
+	TokWhile:    "enquanto",
+	TokMark:     "marcar",
+	TokForward:  "andar",
+	TokTurn:     "virar",
+	TokNot:      "não",
+	TokFunction: "função",
+}
+
+func (k TokenKind) String() string {
+	if int(k) < len(tokenNames) {
+		return tokenNames[k]
+	}
+	return "unknown token"
+}
+
+// arrows maps the arrow symbols to the direction they walk.
+// The unicode arrows are the canonical form, the others are ASCII fallbacks.
+var arrows = map[string]Direction{
+	"←":  West,
+	"↑":  North,
+	"→":  East,
+	"↓":  South,
+	"<-": West,
+	"<":  West,
+	"^":  North,
+	"->": East,
+	">":  East,
+	"v":  South,
+}
+
+// Position locates a token in the source.
+// Offset is in bytes, while Line and Column are 1-based and Column counts characters.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// Token is a single unit of the player language, along with where it was found.
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  Position
+
+	// Dir is the direction of an arrow token
+	Dir Direction
+}
+
+// Lexer splits player code into tokens, recognizing the keywords of a Dialect.
+// It never fails: anything it can't understand becomes a TokIllegal token, left for the parser to report.
+type Lexer struct {
+	src     string
+	pos     Position
+	dialect *Dialect
+}
+
+// NewLexer creates a lexer positioned at the start of src, which is written in dialect.
+func NewLexer(src string, dialect *Dialect) *Lexer {
+	return &Lexer{
+		src:     src,
+		pos:     Position{Offset: 0, Line: 1, Column: 1},
+		dialect: dialect,
+	}
+}
+
+// Tokenize splits src, written in the default dialect, into tokens, ending with a TokEOF token.
+func Tokenize(src string) []Token {
+	return Dialects[0].Tokenize(src)
+}
+
+// Next returns the next token, or TokEOF once the source is exhausted.
+func (l *Lexer) Next() Token {
+	l.skipSpaces()
+
+	start := l.pos
+	r, size := l.peek()
+	if size == 0 {
+		return Token{Kind: TokEOF, Pos: start}
+	}
+
+	switch {
+	case r == '{':
+		l.advance(size)
+		return l.token(TokLBrace, start)
+	case r == '}':
+		l.advance(size)
+		return l.token(TokRBrace, start)
+	case r >= '0' && r <= '9':
+		for r >= '0' && r <= '9' {
+			l.advance(size)
+			r, size = l.peek()
+		}
+		return l.token(TokInt, start)
+	case isIdentStart(r):
+		for isIdentStart(r) || unicode.IsDigit(r) {
+			l.advance(size)
+			r, size = l.peek()
+		}
+		tok := l.token(TokIdent, start)
+		if dir, ok := arrows[tok.Text]; ok {
+			tok.Kind, tok.Dir = TokArrow, dir
+		} else if kind, ok := l.dialect.words[tok.Text]; ok {
+			tok.Kind = kind
+		}
+		return tok
+	}
+
+	// Arrows, trying two-character ASCII arrows before single characters
+	for _, length := range []int{2, 1} {
+		text := l.lookahead(length)
+		if dir, ok := arrows[text]; ok {
+			l.advance(len(text))
+			tok := l.token(TokArrow, start)
+			tok.Dir = dir
+			return tok
+		}
+	}
+
+	l.advance(size)
+	return l.token(TokIllegal, start)
+}
+
+// token creates a token spanning from start to the current position.
+func (l *Lexer) token(kind TokenKind, start Position) Token {
+	return Token{
+		Kind: kind,
+		Text: l.src[start.Offset:l.pos.Offset],
+		Pos:  start,
+	}
+}
+
+// peek decodes the character at the current position without consuming it.
+// Returns a size of 0 at the end of the source.
+func (l *Lexer) peek() (rune, int) {
+	return utf8.DecodeRuneInString(l.src[l.pos.Offset:])
+}
+
+// lookahead returns up to n characters from the current position without consuming them.
+func (l *Lexer) lookahead(n int) string {
+	end := l.pos.Offset
+	for range n {
+		_, size := utf8.DecodeRuneInString(l.src[end:])
+		end += size
+	}
+	return l.src[l.pos.Offset:end]
+}
+
+// advance consumes size bytes, which must end on a character boundary, updating line and column.
+func (l *Lexer) advance(size int) {
+	for _, r := range l.src[l.pos.Offset : l.pos.Offset+size] {
+		if r == '\n' {
+			l.pos.Line++
+			l.pos.Column = 1
+		} else {
+			l.pos.Column++
+		}
+	}
+	l.pos.Offset += size
+}
+
+func (l *Lexer) skipSpaces() {
+	for {
+		r, size := l.peek()
+		if size == 0 || !unicode.IsSpace(r) {
+			return
+		}
+		l.advance(size)
+	}
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
@@ -0,0 +1,98 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTokenize(t *testing.T) {
+	src := "repetir 3 {\n  ← -> v\n}\nse parede { marcar }\nsenão { enquanto }"
+
+	expected := []struct {
+		kind   TokenKind
+		text   string
+		line   int
+		column int
+	}{
+		{TokRepeat, "repetir", 1, 1},
+		{TokInt, "3", 1, 9},
+		{TokLBrace, "{", 1, 11},
+		{TokArrow, "←", 2, 3},
+		{TokArrow, "->", 2, 5},
+		{TokArrow, "v", 2, 8},
+		{TokRBrace, "}", 3, 1},
+		{TokIf, "se", 4, 1},
+		{TokIdent, "parede", 4, 4},
+		{TokLBrace, "{", 4, 11},
+		{TokMark, "marcar", 4, 13},
+		{TokRBrace, "}", 4, 20},
+		{TokElse, "senão", 5, 1},
+		{TokLBrace, "{", 5, 7},
+		{TokWhile, "enquanto", 5, 9},
+		{TokRBrace, "}", 5, 18},
+		{TokEOF, "", 5, 19},
+	}
+
+	tokens := Tokenize(src)
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d: %v", len(expected), len(tokens), tokens)
+	}
+
+	for ix, tok := range tokens {
+		want := expected[ix]
+		if tok.Kind != want.kind || tok.Text != want.text || tok.Pos.Line != want.line || tok.Pos.Column != want.column {
+			t.Errorf("token %d: expected %v %q at %d:%d, got %v %q at %d:%d",
+				ix, want.kind, want.text, want.line, want.column, tok.Kind, tok.Text, tok.Pos.Line, tok.Pos.Column)
+		}
+	}
+
+	if tokens[4].Dir != East || tokens[5].Dir != South || tokens[3].Dir != West {
+		t.Error("arrows point the wrong way")
+	}
+}
+
+func TestTokenizeIllegal(t *testing.T) {
+	tokens := Tokenize("↑ @ vv")
+	kinds := []TokenKind{TokArrow, TokIllegal, TokIdent, TokEOF}
+	for ix, kind := range kinds {
+		if tokens[ix].Kind != kind {
+			t.Errorf("token %d: expected %v, got %v", ix, kind, tokens[ix].Kind)
+		}
+	}
+}
+
+func FuzzLexer(f *testing.F) {
+	f.Add("repetir 3 { ← }")
+	f.Add("se parede { ↓ } senão { → }")
+	f.Add("enquanto não fim {\n\t-> marcar\n}")
+	f.Add("<- <-- ->> ^v 12ab")
+	f.Add("\xff\xfe{}")
+	f.Fuzz(func(t *testing.T, src string) {
+		tokens := Tokenize(src)
+		last := tokens[len(tokens)-1]
+		if last.Kind != TokEOF || last.Pos.Offset != len(src) {
+			t.Fatalf("expected to end with EOF at %d, got %v at %d", len(src), last.Kind, last.Pos.Offset)
+		}
+
+		offset := 0
+		for _, tok := range tokens[:len(tokens)-1] {
+			if tok.Pos.Offset < offset || tok.Text == "" {
+				t.Fatalf("token %q at %d overlaps the previous one", tok.Text, tok.Pos.Offset)
+			}
+			if src[tok.Pos.Offset:tok.Pos.Offset+len(tok.Text)] != tok.Text {
+				t.Fatalf("token %q doesn't match the source at %d", tok.Text, tok.Pos.Offset)
+			}
+
+			// Line and column must agree with the text before the token
+			before := src[:tok.Pos.Offset]
+			line := strings.Count(before, "\n") + 1
+			column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+			if tok.Pos.Line != line || tok.Pos.Column != column {
+				t.Fatalf("token %q at %d:%d, expected %d:%d", tok.Text, tok.Pos.Line, tok.Pos.Column, line, column)
+			}
+
+			offset = tok.Pos.Offset + len(tok.Text)
+		}
+	})
+}
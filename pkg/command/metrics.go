@@ -0,0 +1,43 @@
+package command
+
+// Metrics describe the size of a program, used to limit and score solutions.
+type Metrics struct {
+	// Blocks counts every statement and function definition, the same way they'd be counted as puzzle pieces
+	Blocks int
+
+	// Depth is how many blocks deep the most nested statement is; a program without `{}` has depth 0
+	Depth int
+}
+
+// Measure computes the Metrics of program.
+func Measure(program *Program) Metrics {
+	var m Metrics
+	m.measure(program.Body, 0)
+	for _, fn := range program.Functions {
+		m.Blocks++
+		m.measure(fn.Body, 1)
+	}
+	return m
+}
+
+// measure adds the statements of block, which is nested depth levels deep, to m.
+func (m *Metrics) measure(block *Block, depth int) {
+	if block == nil {
+		return
+	}
+
+	for _, stmt := range block.Stmts {
+		m.Blocks++
+		m.Depth = max(m.Depth, depth)
+
+		switch s := stmt.(type) {
+		case *Repeat:
+			m.measure(s.Body, depth+1)
+		case *If:
+			m.measure(s.Then, depth+1)
+			m.measure(s.Else, depth+1)
+		case *While:
+			m.measure(s.Body, depth+1)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package command
+
+import "testing"
+
+func TestMeasure(t *testing.T) {
+	cases := []struct {
+		src      string
+		expected Metrics
+	}{
+		{"", Metrics{}},
+		{"→ → ↓ ↓", Metrics{Blocks: 4, Depth: 0}},
+		{"repetir 2 { → ↓ }", Metrics{Blocks: 3, Depth: 1}},
+		{"repetir 2 { } ↓", Metrics{Blocks: 2, Depth: 0}},
+		{"se parede { virar ← } senão { enquanto não fim { andar } }", Metrics{Blocks: 4, Depth: 2}},
+		{"função f { → } f f", Metrics{Blocks: 4, Depth: 1}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.src, func(t *testing.T) {
+			program, err := Parse(tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if metrics := Measure(program); metrics != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, metrics)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/hkupty/mirkwood/pkg/catalog"
+)
+
+// MaxRepeat is the largest count accepted by `repetir`.
+const MaxRepeat = 999
+
+// ParseError reports a mistake in a player program, pointing at the code that caused it.
+// Msg is written for the player, not for developers, in the language of the dialect the program was written in.
+type ParseError struct {
+	Src Span
+	Msg string
+
+	// Key and Args identify Msg, so it can be written by another catalog.Catalog
+	Key  catalog.Key
+	Args []any
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Src.Start.Line, e.Src.Start.Column, e.Msg)
+}
+
+// Localize writes the error in the language of c, keeping Msg when it has no Key.
+func (e *ParseError) Localize(c *catalog.Catalog) string {
+	msg := e.Msg
+	if e.Key != "" {
+		msg = c.Text(e.Key, e.Args...)
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Src.Start.Line, e.Src.Start.Column, msg)
+}
+
+func (e *ParseError) Span() Span {
+	return e.Src
+}
+
+// Parse turns player code, written in the default dialect, into a Program.
+// Returns a *ParseError for the first mistake found.
+func Parse(src string) (*Program, error) {
+	return Dialects[0].Parse(src)
+}
+
+func parse(src string, dialect *Dialect) (*Program, error) {
+	p := &parser{tokens: dialect.Tokenize(src), dialect: dialect, functions: map[string]*Function{}}
+
+	start := p.peek().Pos
+	stmts, err := p.stmts()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.Kind != TokEOF {
+		// The only token that stops a statement list early is a stray `}`
+		return nil, p.errorf(tok, msgStrayBrace)
+	}
+
+	// Functions can be called before they are defined, so calls are only resolved at the end
+	for _, call := range p.calls {
+		call.Target = p.functions[call.Name]
+		if call.Target == nil {
+			return nil, dialect.errorf(call.Src, msgUndefinedFunction, dialect.keyword(TokFunction, false), call.Name)
+		}
+	}
+
+	return &Program{
+		Body:      &Block{Stmts: stmts, Src: Span{Start: start, End: p.peek().Pos}},
+		Dialect:   dialect,
+		Functions: p.defined,
+	}, nil
+}
+
+// parser is a recursive descent parser over the tokens of a program.
+type parser struct {
+	tokens  []Token
+	pos     int
+	dialect *Dialect
+
+	// depth is how many blocks deep the parser is
+	depth int
+
+	// functions holds the functions defined so far by name, while defined keeps their order
+	functions map[string]*Function
+	defined   []*Function
+
+	// calls are resolved once every function is known
+	calls []*Call
+}
+
+func (p *parser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() Token {
+	tok := p.tokens[p.pos]
+	if tok.Kind != TokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// last returns the most recently consumed token.
+func (p *parser) last() Token {
+	return p.tokens[max(p.pos-1, 0)]
+}
+
+func (p *parser) errorf(tok Token, msg catalog.Key, args ...any) error {
+	return p.dialect.errorf(tokenSpan(tok), msg, args...)
+}
+
+// stmts parses statements until the end of the program or of the enclosing block.
+func (p *parser) stmts() ([]Stmt, error) {
+	var stmts []Stmt
+	for {
+		switch p.peek().Kind {
+		case TokEOF, TokRBrace:
+			return stmts, nil
+		case TokFunction:
+			if err := p.function(p.next()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		stmt, err := p.stmt()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+}
+
+func (p *parser) stmt() (Stmt, error) {
+	tok := p.next()
+	switch tok.Kind {
+	case TokArrow:
+		return &Do{Action: Walk{Dir: tok.Dir}, Src: tokenSpan(tok)}, nil
+	case TokMark:
+		return &Do{Action: Mark{}, Src: tokenSpan(tok)}, nil
+	case TokForward:
+		return &Do{Action: Forward{}, Src: tokenSpan(tok)}, nil
+	case TokTurn:
+		return p.turn(tok)
+	case TokRepeat:
+		return p.repeat(tok)
+	case TokIf:
+		return p.ifStmt(tok)
+	case TokWhile:
+		return p.while(tok)
+	case TokIdent:
+		call := &Call{Name: tok.Text, Src: tokenSpan(tok)}
+		p.calls = append(p.calls, call)
+		return call, nil
+	case TokElse:
+		return nil, p.errorf(tok, msgElseWithoutIf, tok.Text, p.dialect.keyword(TokIf, false))
+	case TokInt:
+		return nil, p.errorf(tok, msgLonelyNumber, p.dialect.keyword(TokRepeat, false), tok.Text)
+	case TokIllegal:
+		return nil, p.errorf(tok, msgIllegal, tok.Text)
+	default:
+		return nil, p.errorf(tok, msgUnexpected, tok.Text)
+	}
+}
+
+// turn parses `virar ←` or `virar →`
+func (p *parser) turn(start Token) (Stmt, error) {
+	tok := p.next()
+	if tok.Kind == TokArrow {
+		switch tok.Dir {
+		case West:
+			return &Do{Action: Turn{Rot: Left}, Src: p.span(start)}, nil
+		case East:
+			return &Do{Action: Turn{Rot: Right}, Src: p.span(start)}, nil
+		}
+	}
+	return nil, p.errorf(tok, msgTurnSide, start.Text)
+}
+
+// repeat parses `repetir N { ... }`
+func (p *parser) repeat(start Token) (Stmt, error) {
+	tok := p.next()
+	if tok.Kind != TokInt {
+		return nil, p.errorf(tok, msgRepeatCount, start.Text)
+	}
+
+	count, err := strconv.Atoi(tok.Text)
+	if err != nil || count > MaxRepeat {
+		return nil, p.errorf(tok, msgRepeatTooMany, MaxRepeat)
+	}
+
+	body, err := p.block(start)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repeat{Count: count, Body: body, Src: p.span(start)}, nil
+}
+
+// ifStmt parses `se condition { ... }`, optionally followed by `senão { ... }`
+func (p *parser) ifStmt(start Token) (Stmt, error) {
+	cond, err := p.condition(start)
+	if err != nil {
+		return nil, err
+	}
+
+	then, err := p.block(start)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &If{Cond: cond, Then: then}
+	if p.peek().Kind == TokElse {
+		elseTok := p.next()
+		stmt.Else, err = p.block(elseTok)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stmt.Src = p.span(start)
+	return stmt, nil
+}
+
+// while parses `enquanto condition { ... }`
+func (p *parser) while(start Token) (Stmt, error) {
+	cond, err := p.condition(start)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.block(start)
+	if err != nil {
+		return nil, err
+	}
+
+	return &While{Cond: cond, Body: body, Src: p.span(start)}, nil
+}
+
+// condition parses a sensor name, optionally negated and followed by an arrow:
+// `parede`, `parede ↓`, `não marcado`
+func (p *parser) condition(start Token) (Condition, error) {
+	tok := p.next()
+	first := tok
+
+	negated := tok.Kind == TokNot
+	if negated {
+		tok = p.next()
+	}
+
+	if tok.Kind != TokIdent {
+		return Condition{}, p.errorf(tok, msgConditionMissing, start.Text, p.dialect.sensor(Condition{Sensor: SensorWallAhead}, false))
+	}
+
+	sensor, ok := p.dialect.sensorWords[tok.Text]
+	if !ok {
+		return Condition{}, p.errorf(tok, msgUnknownSensor, tok.Text)
+	}
+
+	cond := Condition{Sensor: sensor, Negated: negated}
+	if sensor == SensorWallAhead && p.peek().Kind == TokArrow {
+		cond.Sensor = SensorWall
+		cond.Dir = p.next().Dir
+	}
+
+	cond.Src = p.span(first)
+	return cond, nil
+}
+
+// function parses `função name { ... }`, which can only be defined outside of any other block.
+func (p *parser) function(start Token) error {
+	if p.depth > 0 {
+		return p.errorf(start, msgNestedFunction, start.Text)
+	}
+
+	name := p.next()
+	if name.Kind != TokIdent {
+		return p.errorf(name, msgFunctionName, start.Text, p.dialect.keyword(TokTurn, false), p.dialect.keyword(TokForward, false))
+	}
+	if _, ok := p.dialect.sensorWords[name.Text]; ok {
+		return p.errorf(name, msgNameIsSensor, name.Text)
+	}
+	if previous, ok := p.functions[name.Text]; ok {
+		return p.errorf(name, msgDuplicateFunction, start.Text, name.Text, previous.Src.Start.Line)
+	}
+
+	body, err := p.block(start)
+	if err != nil {
+		return err
+	}
+
+	fn := &Function{Name: name.Text, Body: body, Src: p.span(start)}
+	p.functions[fn.Name] = fn
+	p.defined = append(p.defined, fn)
+	return nil
+}
+
+// block parses `{ ... }`, owned by the statement starting at owner.
+func (p *parser) block(owner Token) (*Block, error) {
+	open := p.next()
+	if open.Kind != TokLBrace {
+		return nil, p.errorf(open, msgBlockOpen, owner.Text)
+	}
+
+	p.depth++
+	stmts, err := p.stmts()
+	p.depth--
+	if err != nil {
+		return nil, err
+	}
+
+	if p.next().Kind != TokRBrace {
+		return nil, p.errorf(open, msgBlockUnclosed)
+	}
+
+	return &Block{Stmts: stmts, Src: p.span(open)}, nil
+}
+
+// span covers from the start token up to the last consumed token.
+func (p *parser) span(start Token) Span {
+	return Span{Start: start.Pos, End: tokenSpan(p.last()).End}
+}
+
+// tokenSpan covers a single token. Tokens never span multiple lines.
+func tokenSpan(tok Token) Span {
+	end := tok.Pos
+	end.Offset += len(tok.Text)
+	end.Column += utf8.RuneCountInString(tok.Text)
+	return Span{Start: tok.Pos, End: end}
+}
@@ -0,0 +1,135 @@
+package command
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	program, err := Parse("repetir 3 { ← }\nse parede ↓ {\n  marcar\n} senão { virar → andar }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmts := program.Body.Stmts
+	if len(stmts) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(stmts))
+	}
+
+	repeat, ok := stmts[0].(*Repeat)
+	if !ok || repeat.Count != 3 || len(repeat.Body.Stmts) != 1 {
+		t.Fatalf("expected repetir 3 with a single statement, got %#v", stmts[0])
+	}
+	if do, ok := repeat.Body.Stmts[0].(*Do); !ok || do.Action != (Walk{Dir: West}) {
+		t.Fatalf("expected to walk west, got %#v", repeat.Body.Stmts[0])
+	}
+
+	cond, ok := stmts[1].(*If)
+	if !ok || cond.Cond.Sensor != SensorWall || cond.Cond.Dir != South {
+		t.Fatalf("expected se parede ↓, got %#v", stmts[1])
+	}
+	if cond.Else == nil || len(cond.Else.Stmts) != 2 {
+		t.Fatal("expected senão with two statements")
+	}
+	if do := cond.Else.Stmts[0].(*Do); do.Action != (Turn{Rot: Right}) {
+		t.Fatalf("expected to turn right, got %#v", do.Action)
+	}
+
+	span := cond.Span()
+	if span.Start.Line != 2 || span.Start.Column != 1 || span.End.Line != 4 || span.End.Column != 26 {
+		t.Errorf("unexpected span for se: %+v", span)
+	}
+}
+
+func TestParseFunctions(t *testing.T) {
+	program, err := Parse("f\nfunção f { → g }\nfunção g { ↓ }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(program.Functions) != 2 || program.Functions[0].Name != "f" || program.Functions[1].Name != "g" {
+		t.Fatalf("expected functions f and g, got %v", program.Functions)
+	}
+
+	// Definitions are not statements, only the call is left in the body
+	if len(program.Body.Stmts) != 1 {
+		t.Fatalf("expected a single statement, got %d", len(program.Body.Stmts))
+	}
+
+	call, ok := program.Body.Stmts[0].(*Call)
+	if !ok || call.Target != program.Functions[0] {
+		t.Fatalf("expected a call to f, got %#v", program.Body.Stmts[0])
+	}
+
+	inner, ok := program.Functions[0].Body.Stmts[1].(*Call)
+	if !ok || inner.Target != program.Functions[1] {
+		t.Fatalf("expected f to call g, got %#v", program.Functions[0].Body.Stmts[1])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		src    string
+		line   int
+		column int
+	}{
+		{"illegal character", "→ @", 1, 3},
+		{"repeat without count", "repetir { → }", 1, 9},
+		{"repeat too many times", "repetir 1000 { → }", 1, 9},
+		{"missing open brace", "repetir 3 →", 1, 11},
+		{"unclosed block", "repetir 3 {\n →", 1, 11},
+		{"stray close brace", "→ }", 1, 3},
+		{"unknown sensor", "se arvore { → }", 1, 4},
+		{"if without condition", "se { → }", 1, 4},
+		{"else without if", "→ senão { → }", 1, 3},
+		{"turn without side", "virar ↑", 1, 7},
+		{"lonely number", "3", 1, 1},
+		{"while without condition", "enquanto não { → }", 1, 14},
+		{"while without block", "enquanto fim →", 1, 14},
+		{"undefined function", "→\n  pular", 2, 3},
+		{"duplicate function", "função f { → }\nfunção f { ← }", 2, 8},
+		{"function without name", "função { → }", 1, 8},
+		{"function named after sensor", "função fim { → }", 1, 8},
+		{"nested function", "repetir 2 { função f { → } }", 1, 13},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.src)
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+
+			if parseErr.Src.Start.Line != tc.line || parseErr.Src.Start.Column != tc.column {
+				t.Errorf("expected error at %d:%d, got %v", tc.line, tc.column, err)
+			}
+		})
+	}
+}
+
+func FuzzParser(f *testing.F) {
+	f.Add("repetir 3 { ← }")
+	f.Add("se parede { ↓ } senão { → }")
+	f.Add("se parede_esquerda { virar ← } andar marcar")
+	f.Add("repetir 2 { repetir 2 { -> v } }")
+	f.Add("enquanto não parede { andar } enquanto parede ↓ { → }")
+	f.Add("função f { se não fim { andar f } } f")
+	f.Add("}{")
+	f.Fuzz(func(t *testing.T, src string) {
+		program, err := Parse(src)
+		if err != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			return
+		}
+
+		if program.Body == nil {
+			t.Fatal("parsed program without a body")
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package command
+
+// Feature is a construct of the player language that a level may or may not allow,
+// so early levels can't be solved with tools the player hasn't learned yet.
+// Features are combined with `|` to form a set, where the zero value stands for every feature.
+type Feature uint16
+
+const (
+	FeatureWalk Feature = 1 << iota
+	FeatureTurn
+	FeatureForward
+	FeatureMark
+	FeatureRepeat
+	FeatureIf
+	FeatureWhile
+	FeatureFunction
+)
+
+// AllFeatures has every construct of the language.
+const AllFeatures Feature = FeatureWalk | FeatureTurn | FeatureForward | FeatureMark |
+	FeatureRepeat | FeatureIf | FeatureWhile | FeatureFunction
+
+// The curriculum introduces the language a little at a time, each stage building on the previous one.
+const (
+	StageArrows       = FeatureWalk
+	StageLoops        = StageArrows | FeatureRepeat
+	StageConditionals = StageLoops | FeatureTurn | FeatureForward | FeatureIf
+	StageMarks        = StageConditionals | FeatureMark
+)
+
+// String is how the feature is written in programs of the default dialect.
+func (f Feature) String() string {
+	return Dialects[0].feature(f)
+}
+
+// Has reports whether every feature in other is part of the set f.
+func (f Feature) Has(other Feature) bool {
+	return f == 0 || f&other == other
+}
+
+// ActionFeature returns the feature needed to perform action.
+func ActionFeature(action Action) Feature {
+	switch action.(type) {
+	case Walk:
+		return FeatureWalk
+	case Turn:
+		return FeatureTurn
+	case Forward:
+		return FeatureForward
+	case Mark:
+		return FeatureMark
+	default:
+		return 0
+	}
+}
+
+// Rules restrict which programs are accepted by a level.
+// The zero value accepts any program.
+type Rules struct {
+	// Allowed are the features programs can use
+	Allowed Feature
+
+	// MaxBlocks is the largest program accepted, counting every statement and function as a block (0 = unlimited)
+	MaxBlocks int
+
+	// MaxDepth is how deep blocks can be nested inside each other (0 = unlimited)
+	MaxDepth int
+}
+
+// Check reports the first part of program that breaks the rules,
+// as a *ParseError pointing at it so it is reported like any other mistake in the code.
+func (r Rules) Check(program *Program) error {
+	for _, stmt := range program.Body.Stmts {
+		if err := r.checkStmt(program, stmt); err != nil {
+			return err
+		}
+	}
+
+	for _, fn := range program.Functions {
+		if !r.Allowed.Has(FeatureFunction) {
+			return notLearned(program, fn, FeatureFunction)
+		}
+		for _, stmt := range fn.Body.Stmts {
+			if err := r.checkStmt(program, stmt); err != nil {
+				return err
+			}
+		}
+	}
+
+	metrics := Measure(program)
+	if r.MaxBlocks > 0 && metrics.Blocks > r.MaxBlocks {
+		return dialectOf(program).errorf(program.Span(), msgTooManyBlocks, metrics.Blocks, r.MaxBlocks)
+	}
+	if r.MaxDepth > 0 && metrics.Depth > r.MaxDepth {
+		return dialectOf(program).errorf(program.Span(), msgTooDeep, metrics.Depth, r.MaxDepth)
+	}
+
+	return nil
+}
+
+func (r Rules) checkStmt(program *Program, stmt Stmt) error {
+	var feature Feature
+	var blocks []*Block
+
+	switch s := stmt.(type) {
+	case *Do:
+		feature = ActionFeature(s.Action)
+	case *Repeat:
+		feature, blocks = FeatureRepeat, []*Block{s.Body}
+	case *If:
+		feature, blocks = FeatureIf, []*Block{s.Then, s.Else}
+	case *While:
+		feature, blocks = FeatureWhile, []*Block{s.Body}
+	case *Call:
+		feature = FeatureFunction
+	}
+
+	if !r.Allowed.Has(feature) {
+		return notLearned(program, stmt, feature)
+	}
+
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		for _, inner := range block.Stmts {
+			if err := r.checkStmt(program, inner); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func notLearned(program *Program, node Node, feature Feature) error {
+	dialect := dialectOf(program)
+	return dialect.errorf(node.Span(), msgNotLearned, dialect.feature(feature))
+}
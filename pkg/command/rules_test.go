@@ -0,0 +1,85 @@
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRulesCheck(t *testing.T) {
+	cases := []struct {
+		name    string
+		src     string
+		rules   Rules
+		line    int
+		column  int
+		missing string
+	}{
+		{"repeat on arrows stage", "→\nrepetir 2 { ↓ }", Rules{Allowed: StageArrows}, 2, 1, "repetir"},
+		{"if nested in repeat", "repetir 2 {\n  se parede { ↓ }\n}", Rules{Allowed: StageLoops}, 2, 3, "se"},
+		{"mark in else", "se parede { andar } senão { marcar }", Rules{Allowed: StageConditionals}, 1, 29, "marcar"},
+		{"function definition", "→\nfunção f { → }", Rules{Allowed: StageMarks}, 2, 1, "função"},
+		{"inside function", "função f { enquanto fim { → } } f", Rules{Allowed: StageMarks | FeatureFunction}, 1, 12, "enquanto"},
+		{"too many blocks", "→ → →\nrepetir 2 { ↓ }", Rules{MaxBlocks: 4}, 1, 1, "5 blocos"},
+		{"too deep", "repetir 2 { repetir 2 { → } }", Rules{MaxDepth: 1}, 1, 1, "2 níveis"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := Parse(tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tc.rules.Check(program)
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if parseErr.Src.Start.Line != tc.line || parseErr.Src.Start.Column != tc.column {
+				t.Errorf("expected error at %d:%d, got %v", tc.line, tc.column, err)
+			}
+			if !strings.Contains(parseErr.Msg, tc.missing) {
+				t.Errorf("expected the error to mention %q, got %v", tc.missing, err)
+			}
+		})
+	}
+}
+
+func TestRulesAccept(t *testing.T) {
+	program, err := Parse("função f { → } repetir 2 { f }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := Rules{Allowed: StageArrows | FeatureRepeat | FeatureFunction, MaxBlocks: 4}
+	if err := rules.Check(program); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func FuzzRules(f *testing.F) {
+	f.Add("repetir 3 { ← }", uint16(StageArrows))
+	f.Add("se parede { ↓ } senão { marcar }", uint16(StageConditionals))
+	f.Add("função f { se não fim { andar f } } f", uint16(AllFeatures))
+	f.Fuzz(func(t *testing.T, src string, allowed uint16) {
+		program, err := Parse(src)
+		if err != nil {
+			return
+		}
+
+		// Programs are only rejected for features that are not allowed
+		if err := (Rules{}).Check(program); err != nil {
+			t.Fatalf("unrestricted rules rejected the program: %v", err)
+		}
+		if err := (Rules{Allowed: AllFeatures}).Check(program); err != nil {
+			t.Fatalf("every feature is allowed, but the program was rejected: %v", err)
+		}
+
+		err = Rules{Allowed: Feature(allowed)}.Check(program)
+		if err != nil && !strings.Contains(err.Error(), "ainda não aprendeu") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
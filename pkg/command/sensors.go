@@ -0,0 +1,29 @@
+package command
+
+// Sensors is everything a player program can know about the world while it runs.
+// It is deliberately small: the player senses their immediate surroundings and nothing else,
+// so engine knowledge (the maze layout, visited cells, paths) never leaks into the language.
+type Sensors interface {
+	// WallAhead reports whether the cell in front of the player is blocked
+	WallAhead() bool
+
+	// WallLeft reports whether the cell to the left of the player is blocked
+	WallLeft() bool
+
+	// WallRight reports whether the cell to the right of the player is blocked
+	WallRight() bool
+
+	// Wall reports whether the cell in an absolute direction is blocked
+	Wall(dir Direction) bool
+
+	// IsMarked reports whether the player is standing on a marked cell
+	IsMarked() bool
+
+	// AtFinish reports whether the player is standing on the finishing point
+	AtFinish() bool
+
+	// Fingerprint identifies everything about the world the sensors could ever tell apart.
+	// It must be comparable: two equal fingerprints mean the program can't distinguish both worlds,
+	// which the interpreter relies on to detect loops that never end.
+	Fingerprint() any
+}
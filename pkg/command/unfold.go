@@ -0,0 +1,120 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/hkupty/mirkwood/pkg/catalog"
+)
+
+var (
+	// ErrSensorDependent indicates a program whose actions depend on what it senses, so they can't be known in advance
+	ErrSensorDependent = catalog.New("command.sensor_dependent")
+
+	// ErrTooLong indicates a program that unfolds to more actions than allowed
+	ErrTooLong = catalog.New("command.too_long")
+)
+
+// UnfoldError reports why a program can't be unfolded, pointing at the code that caused it.
+type UnfoldError struct {
+	Src Span
+	Err error
+}
+
+func (e *UnfoldError) Error() string {
+	return fmt.Sprintf("%d:%d: %v", e.Src.Start.Line, e.Src.Start.Column, e.Err)
+}
+
+// Localize writes the error in the language of c.
+func (e *UnfoldError) Localize(c *catalog.Catalog) string {
+	return fmt.Sprintf("%d:%d: %s", e.Src.Start.Line, e.Src.Start.Column, c.Render(e.Err))
+}
+
+func (e *UnfoldError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UnfoldError) Span() Span {
+	return e.Src
+}
+
+// Unfold flattens program into the actions it performs, showing what loops and functions really do.
+// Only programs that never check their surroundings (no `se` nor `enquanto`) can be unfolded,
+// and at most limit actions are produced (0 = unlimited).
+// Running the returned actions is the same as running the program through the Interpreter.
+func Unfold(program *Program, limit int) ([]Action, error) {
+	u := unfolder{limit: limit, active: map[*Function]bool{}, unfolded: map[*Function][]Action{}}
+	return u.block(program.Body, 0)
+}
+
+// unfolder flattens blocks, keeping track of the functions being unfolded to catch recursion.
+type unfolder struct {
+	limit  int
+	active map[*Function]bool
+
+	// unfolded remembers the actions of each function already unfolded, since they are the same wherever it is called.
+	// Without it, functions calling each other several times would be walked over and over again,
+	// taking exponentially longer even when they produce no actions at all.
+	unfolded map[*Function][]Action
+}
+
+// block unfolds the statements of b, where prefix actions were already produced before it.
+func (u *unfolder) block(b *Block, prefix int) ([]Action, error) {
+	var actions []Action
+	for _, stmt := range b.Stmts {
+		var next []Action
+		switch s := stmt.(type) {
+		case *Do:
+			next = []Action{s.Action}
+		case *Repeat:
+			if s.Count == 0 {
+				continue
+			}
+
+			body, err := u.block(s.Body, prefix+len(actions))
+			if err != nil {
+				return nil, err
+			}
+			if err := u.fits(s, prefix+len(actions)+len(body)*s.Count); err != nil {
+				return nil, err
+			}
+
+			for range s.Count {
+				next = append(next, body...)
+			}
+		case *If, *While:
+			return nil, &UnfoldError{Src: s.Span(), Err: ErrSensorDependent}
+		case *Call:
+			// Without conditions nothing can stop a recursion, so it goes on forever
+			if u.active[s.Target] {
+				return nil, &UnfoldError{Src: s.Span(), Err: ErrInfiniteLoop}
+			}
+
+			body, ok := u.unfolded[s.Target]
+			if !ok {
+				var err error
+				u.active[s.Target] = true
+				body, err = u.block(s.Target.Body, prefix+len(actions))
+				u.active[s.Target] = false
+				if err != nil {
+					return nil, err
+				}
+				u.unfolded[s.Target] = body
+			}
+			next = body
+		}
+
+		if err := u.fits(stmt, prefix+len(actions)+len(next)); err != nil {
+			return nil, err
+		}
+		actions = append(actions, next...)
+	}
+	return actions, nil
+}
+
+// fits fails when size actions are more than allowed, blaming node for it.
+func (u *unfolder) fits(node Node, size int) error {
+	if u.limit > 0 && size > u.limit {
+		return &UnfoldError{Src: node.Span(), Err: ErrTooLong}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUnfold(t *testing.T) {
+	program, err := Parse("função f { → ↓ }\nrepetir 2 { f repetir 0 { ← } }\nmarcar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actions, err := Unfold(program, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Action{Walk{Dir: East}, Walk{Dir: South}, Walk{Dir: East}, Walk{Dir: South}, Mark{}}
+	if !slices.Equal(actions, expected) {
+		t.Fatalf("expected %v, got %v", expected, actions)
+	}
+}
+
+func TestUnfoldNestedCalls(t *testing.T) {
+	// Each function calls the next one four times, so walking every call would take 4^12 of them
+	var src strings.Builder
+	for ix := range 12 {
+		fmt.Fprintf(&src, "função f%d { f%d f%d f%d f%d }\n", ix, ix+1, ix+1, ix+1, ix+1)
+	}
+	src.WriteString("função f12 { }\nf0 →")
+
+	program, err := Parse(src.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actions, err := Unfold(program, 1000)
+	if err != nil || !slices.Equal(actions, []Action{Walk{Dir: East}}) {
+		t.Fatalf("expected a single step, got %v (%v)", actions, err)
+	}
+}
+
+func TestUnfoldErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		src    string
+		limit  int
+		err    error
+		line   int
+		column int
+	}{
+		{"if", "→\nrepetir 2 { se parede { → } }", 0, ErrSensorDependent, 2, 13},
+		{"while inside function", "função f { enquanto fim { → } }\nf", 0, ErrSensorDependent, 1, 12},
+		{"over the limit", "→ → → →", 3, ErrTooLong, 1, 7},
+		{"repeat over the limit", "→\nrepetir 999 { repetir 999 { → } }", 1000, ErrTooLong, 2, 1},
+		{"recursion", "função f {\n  → f\n}\nf", 0, ErrInfiniteLoop, 2, 5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := Parse(tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = Unfold(program, tc.limit)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			var unfoldErr *UnfoldError
+			if !errors.As(err, &unfoldErr) {
+				t.Fatalf("expected an UnfoldError, got %v", err)
+			}
+			if span := unfoldErr.Span(); span.Start.Line != tc.line || span.Start.Column != tc.column {
+				t.Errorf("expected error at %d:%d, got %v", tc.line, tc.column, err)
+			}
+		})
+	}
+}
+
+func FuzzUnfold(f *testing.F) {
+	f.Add("repetir 3 { ← }")
+	f.Add("função f { → ↓ } repetir 2 { f } marcar")
+	f.Add("repetir 9 { repetir 9 { repetir 9 { virar → andar } } }")
+	f.Add("repetir 999 { repetir 999 { } }")
+	f.Add("função a { b b b b } função b { c c c c } função c { d d d d } função d { e e e e } função e { }\nrepetir 999 { a a a a }")
+	f.Fuzz(func(t *testing.T, src string) {
+		program, err := Parse(src)
+		if err != nil {
+			return
+		}
+
+		actions, err := Unfold(program, 1000)
+		if err != nil {
+			var unfoldErr *UnfoldError
+			if !errors.As(err, &unfoldErr) {
+				t.Fatalf("expected an UnfoldError, got %v", err)
+			}
+			return
+		}
+
+		if len(actions) > 1000 {
+			t.Fatalf("unfolded to %d actions, over the limit", len(actions))
+		}
+
+		// Actions print as code, so the unfolded program can be read back
+		lines := make([]string, len(actions))
+		for ix, action := range actions {
+			lines[ix] = action.String()
+		}
+		flat, err := Parse(strings.Join(lines, "\n"))
+		if err != nil {
+			t.Fatalf("unfolded program doesn't parse: %v", err)
+		}
+		if again, err := Unfold(flat, 0); err != nil || !slices.Equal(actions, again) {
+			t.Fatalf("unfolded program reads back as %v (%v)", again, err)
+		}
+
+		// Unfolded programs don't sense anything, so the interpreter must do exactly the same,
+		// unless looping over empty blocks runs it out of budget before it gets there
+		traced, err := record(src)
+		if errors.Is(err, ErrInfiniteLoop) {
+			return
+		}
+		if err != nil || !slices.Equal(actions, traced) {
+			t.Fatalf("unfolded %v, but the interpreter did %v (%v)", actions, traced, err)
+		}
+	})
+}
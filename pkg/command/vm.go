@@ -0,0 +1,151 @@
+package command
+
+import "maps"
+
+// VM runs compiled Code one action at a time, the same way the Interpreter runs a Program.
+// All of its state is a program counter and a couple of small stacks,
+// so it can be paused anywhere, copied with Snapshot and resumed later.
+type VM struct {
+	code   *Code
+	limits Limits
+
+	// pc is the address of the next instruction
+	pc int
+
+	// returns are the addresses to continue at when each running call returns, with calls being the calls themselves
+	returns []int
+	calls   []*Call
+
+	// loops are the loops currently running, innermost last
+	loops []vmLoop
+
+	origin *Do
+	spent  int
+	halted bool
+}
+
+// vmLoop is a running loop: `repetir` counts its passes, while `enquanto` remembers what it has seen.
+type vmLoop struct {
+	node      Stmt
+	remaining int
+	seen      map[any]struct{}
+}
+
+// NewVM creates a VM positioned at the start of code.
+func NewVM(code *Code, limits Limits) *VM {
+	return &VM{code: code, limits: limits}
+}
+
+// Next runs instructions until the program produces an action, answering conditions with sensors.
+// The caller is expected to perform the action and pass the updated sensors in the following call.
+// Returns done once the program has finished, in which case there is no action.
+func (vm *VM) Next(sensors Sensors) (action Action, done bool, err error) {
+	for !vm.halted {
+		vm.spent++
+		if vm.limits.Budget > 0 && vm.spent > vm.limits.Budget {
+			return nil, false, &RuntimeError{Src: vm.innermostLoop(), Err: ErrOutOfBudget}
+		}
+
+		addr := vm.pc
+		instr := vm.code.Instrs[addr]
+		node := vm.code.Source[addr]
+		vm.pc++
+
+		switch instr.Op {
+		case OpHalt:
+			vm.halted = true
+		case OpWalk:
+			return vm.act(node, Walk{Dir: Direction(instr.Arg)})
+		case OpForward:
+			return vm.act(node, Forward{})
+		case OpTurn:
+			return vm.act(node, Turn{Rot: Rotation(instr.Arg)})
+		case OpMark:
+			return vm.act(node, Mark{})
+		case OpJump:
+			vm.pc = int(instr.Addr)
+		case OpJumpUnless:
+			if !decodeCondition(instr.Arg).Eval(sensors) {
+				vm.pc = int(instr.Addr)
+			}
+		case OpCall:
+			if vm.limits.MaxDepth > 0 && len(vm.calls) >= vm.limits.MaxDepth {
+				return nil, false, &RuntimeError{Src: node.Span(), Err: ErrTooDeep}
+			}
+			vm.returns = append(vm.returns, vm.pc)
+			vm.calls = append(vm.calls, node.(*Call))
+			vm.pc = int(instr.Addr)
+		case OpReturn:
+			last := len(vm.returns) - 1
+			vm.pc = vm.returns[last]
+			vm.returns, vm.calls = vm.returns[:last], vm.calls[:last]
+		case OpRepeat:
+			vm.loops = append(vm.loops, vmLoop{node: node.(Stmt), remaining: int(instr.Addr) - 1})
+		case OpNext:
+			top := &vm.loops[len(vm.loops)-1]
+			if top.remaining > 0 {
+				top.remaining--
+				vm.pc = int(instr.Addr)
+			} else {
+				vm.loops = vm.loops[:len(vm.loops)-1]
+			}
+		case OpWhile:
+			vm.loops = append(vm.loops, vmLoop{node: node.(Stmt), seen: map[any]struct{}{}})
+		case OpSeen:
+			top := &vm.loops[len(vm.loops)-1]
+			fingerprint := sensors.Fingerprint()
+			if _, ok := top.seen[fingerprint]; ok {
+				return nil, false, &RuntimeError{Src: top.node.Span(), Err: ErrInfiniteLoop}
+			}
+			top.seen[fingerprint] = struct{}{}
+		case OpEnd:
+			vm.loops = vm.loops[:len(vm.loops)-1]
+		}
+	}
+
+	vm.origin = nil
+	return nil, true, nil
+}
+
+func (vm *VM) act(node Node, action Action) (Action, bool, error) {
+	vm.origin = node.(*Do)
+	return action, false, nil
+}
+
+// Origin returns the node that produced the last action, so it can be highlighted,
+// or nil if no action was produced yet or the program is done.
+func (vm *VM) Origin() *Do {
+	return vm.origin
+}
+
+// CallStack returns the function calls currently running, outermost first.
+// The returned slice must not be modified.
+func (vm *VM) CallStack() []*Call {
+	return vm.calls
+}
+
+// PC returns the address of the next instruction, which can be looked up in the Code.
+func (vm *VM) PC() int {
+	return vm.pc
+}
+
+// Snapshot copies the VM, so it can be resumed from this point independently of the original.
+func (vm *VM) Snapshot() *VM {
+	snapshot := *vm
+	snapshot.returns = append([]int(nil), vm.returns...)
+	snapshot.calls = append([]*Call(nil), vm.calls...)
+	snapshot.loops = make([]vmLoop, len(vm.loops))
+	for ix, loop := range vm.loops {
+		loop.seen = maps.Clone(loop.seen)
+		snapshot.loops[ix] = loop
+	}
+	return &snapshot
+}
+
+// innermostLoop returns the span of the innermost running loop, or the whole program if there is none.
+func (vm *VM) innermostLoop() Span {
+	if len(vm.loops) > 0 {
+		return vm.loops[len(vm.loops)-1].node.Span()
+	}
+	return vm.code.Src
+}
@@ -0,0 +1,180 @@
+package command
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// runner is what both the Interpreter and the VM do
+type runner interface {
+	Next(sensors Sensors) (Action, bool, error)
+}
+
+// collect runs r with sensors until it finishes, fails or produces limit actions
+func collect(r runner, sensors Sensors, limit int) ([]Action, error) {
+	var actions []Action
+	for len(actions) < limit {
+		action, done, err := r.Next(sensors)
+		if err != nil || done {
+			return actions, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+func compile(t testing.TB, src string) (*Program, *Code) {
+	program, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := Compile(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program, code
+}
+
+func TestDisassemble(t *testing.T) {
+	_, code := compile(t, "repetir 2 { → }\nse não parede ↓ { f }\nfunção f { virar ← }")
+
+	expected := "" +
+		"0000  REPEAT   2                ; 1:1\n" +
+		"0001  WALK     →                ; 1:13\n" +
+		"0002  NEXT     0001             ; 1:1\n" +
+		"0003  JUNLESS  0005 não parede ↓ ; 2:1\n" +
+		"0004  CALL     0006             ; 2:19\n" +
+		"0005  HALT                      ; 1:1\n" +
+		"0006  TURN     virar ←          ; 3:12\n" +
+		"0007  RET                       ; 3:1\n"
+
+	if disassembly := code.String(); disassembly != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, disassembly)
+	}
+}
+
+func TestVM(t *testing.T) {
+	cases := []string{
+		"→ ↓",
+		"repetir 2 { → repetir 2 { ↓ } } repetir 0 { ← }",
+		"se parede { → } senão { ← } se não marcado { marcar }",
+		"função f { → g } função g { ↓ } repetir 2 { f }",
+	}
+
+	for _, src := range cases {
+		t.Run(src, func(t *testing.T) {
+			_, code := compile(t, src)
+
+			actions, err := collect(NewVM(code, DefaultLimits), blindSensors{}, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := trace(t, src); !slices.Equal(actions, expected) {
+				t.Fatalf("expected %v, got %v", expected, actions)
+			}
+		})
+	}
+}
+
+func TestVMErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		src    string
+		limits Limits
+		err    error
+		line   int
+	}{
+		{"enquanto cycle", "↓\nenquanto não fim {\n  →\n}", DefaultLimits, ErrInfiniteLoop, 2},
+		{"out of budget", "repetir 9 {\n  repetir 9 { → }\n}", Limits{Budget: 10}, ErrOutOfBudget, 2},
+		{"too deep", "função f {\n  → f\n}\nf", Limits{MaxDepth: 5}, ErrTooDeep, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, code := compile(t, tc.src)
+
+			_, err := collect(NewVM(code, tc.limits), blindSensors{}, 100)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			var runtimeErr *RuntimeError
+			if !errors.As(err, &runtimeErr) || runtimeErr.Span().Start.Line != tc.line {
+				t.Fatalf("expected the error at line %d, got %v", tc.line, err)
+			}
+		})
+	}
+}
+
+func TestVMSnapshot(t *testing.T) {
+	_, code := compile(t, "função f { → ↓ }\nrepetir 3 { f }")
+
+	vm := NewVM(code, DefaultLimits)
+	if _, err := collect(vm, blindSensors{}, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := vm.Snapshot()
+	rest, err := collect(vm, blindSensors{}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The snapshot resumes from where it was taken, unaffected by the original running to the end
+	again, err := collect(snapshot, blindSensors{}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 3 || !slices.Equal(rest, again) {
+		t.Fatalf("expected the snapshot to do %v, got %v", rest, again)
+	}
+}
+
+func FuzzVM(f *testing.F) {
+	f.Add("repetir 3 { ← }")
+	f.Add("se parede { ↓ } senão { → }")
+	f.Add("função f { se não fim { andar f } } f")
+	f.Add("enquanto não marcado { → } repetir 2 { enquanto fim { } }")
+	f.Fuzz(func(t *testing.T, src string) {
+		program, err := Parse(src)
+		if err != nil {
+			return
+		}
+
+		code, err := Compile(program)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		compareTraces(t, program, code, blindSensors{})
+	})
+}
+
+// compareTraces fails when the interpreter and the VM don't produce the same actions and errors.
+// Budgets are spent differently by each, so only what both did before either ran out of budget is compared.
+func compareTraces(t testing.TB, program *Program, code *Code, sensors Sensors) {
+	limits := Limits{Budget: 10_000, MaxDepth: 50}
+
+	expected, expectedErr := collect(NewInterpreter(program, limits), sensors, 500)
+	actions, err := collect(NewVM(code, limits), sensors, 500)
+
+	if errors.Is(expectedErr, ErrOutOfBudget) || errors.Is(err, ErrOutOfBudget) {
+		size := min(len(expected), len(actions))
+		expected, actions = expected[:size], actions[:size]
+		expectedErr, err = nil, nil
+	}
+
+	if !slices.Equal(expected, actions) {
+		t.Fatalf("interpreter did %v, but the VM did %v", expected, actions)
+	}
+
+	var expectedRuntime, runtime *RuntimeError
+	if errors.As(expectedErr, &expectedRuntime) != errors.As(err, &runtime) {
+		t.Fatalf("interpreter failed with %v, but the VM failed with %v", expectedErr, err)
+	}
+	if expectedRuntime != nil && (expectedRuntime.Err != runtime.Err || expectedRuntime.Src != runtime.Src) {
+		t.Fatalf("interpreter failed with %v, but the VM failed with %v", expectedErr, err)
+	}
+}
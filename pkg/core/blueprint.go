@@ -1,24 +1,40 @@
 package core
 
-import (
-	"github.com/hkupty/mirkwood/pkg/maze"
-)
+import "github.com/hkupty/mirkwood/pkg/maze"
 
 // NewStateFromBlueprint creates a new game State from a LevelBlueprint.
 // This is the primary way to initialize a level's runtime state.
-func NewStateFromBlueprint(bp maze.LevelBlueprint) State {
-	walls := maze.GridToBitBoard(bp.Grid)
-	startPos := maze.BitBoard(1 << bp.StartingPoint)
-	finishPos := maze.BitBoard(1 << bp.FinishingPoint)
+// Returns an error if the blueprint doesn't fit in a board or doesn't produce a valid state.
+func NewStateFromBlueprint(bp maze.LevelBlueprint) (State, error) {
+	walls, err := maze.GridToBitBoard(bp.Grid)
+	if err != nil {
+		return State{}, err
+	}
+	startPos := walls.Empty().Set(bp.StartingPoint)
+	finishPos := walls.Empty().Set(bp.FinishingPoint)
+	edges := maze.NewEdges(walls)
 
-	return State{
+	state := State{
 		Position:     startPos,
+		Heading:      edges.Inward(startPos),
 		VisitedPath:  startPos,
-		Marks:        0,
+		Marks:        walls.Empty(),
 		StepsCounter: 0,
 		Invariants: LevelInvariants{
 			Walls:          walls,
 			FinishingPoint: finishPos,
+			Edges:          edges,
+			WinCondition:   bp.WinCondition,
 		},
 	}
+
+	if finishPos.IsZero() {
+		return State{}, ErrInvalidState
+	}
+
+	if err := state.IsValid(); err != nil {
+		return State{}, err
+	}
+
+	return state, nil
 }
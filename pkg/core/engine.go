@@ -1,27 +1,70 @@
 package core
 
 import (
-	"fmt"
-
 	"github.com/hkupty/mirkwood/pkg/command"
 )
 
-func Step(state State, action any) (State, error) {
-	var nextState State
-	var err error
-
-	switch v := action.(type) {
-	case command.Walk:
-		nextState, err = state.Move(v.Dir)
-	case command.Mark:
-		nextState = state.ToggleMark()
-	default:
-		return state, fmt.Errorf("unknown action type: %T", action)
-	}
+// rules performs each action over the game State.
+type rules struct{}
+
+var _ command.Handler[State] = rules{}
+
+func (rules) Walk(state State, action command.Walk) (State, error) {
+	return state.Move(action.Dir)
+}
+
+func (rules) Turn(state State, action command.Turn) (State, error) {
+	return state.Turn(action.Rot), nil
+}
+
+func (rules) Forward(state State, action command.Forward) (State, error) {
+	return state.Move(state.Heading)
+}
+
+func (rules) Mark(state State, action command.Mark) (State, error) {
+	return state.ToggleMark(), nil
+}
 
+// Step performs a single action, counting its cost towards the step limit.
+// The cost replaces the step counted by Move, so every action is counted exactly once.
+// Returns the unchanged state and an error if the action can't be performed.
+func Step(state State, action command.Action) (State, error) {
+	nextState, err := command.Apply[State](rules{}, state, action)
 	if err != nil {
 		return state, err
 	}
 
+	nextState.StepsCounter = state.StepsCounter + action.Cost()
+	if nextState.exceedsStepLimit() {
+		return state, ErrStepLimit
+	}
+
 	return nextState, nil
 }
+
+// Run compiles program and plays it from state until it is done or the player reaches the finish,
+// one Step at a time, the same way the TUI does.
+// Returns the last state, along with the first error or the result of checking it with IsCompleteWith.
+func Run(state State, program *command.Program, limits command.Limits) (State, error) {
+	code, err := command.Compile(program)
+	if err != nil {
+		return state, err
+	}
+
+	vm := command.NewVM(code, limits)
+	for !state.IsAtFinish() {
+		action, done, err := vm.Next(state.Sensors())
+		if err != nil {
+			return state, err
+		}
+		if done {
+			break
+		}
+
+		if state, err = Step(state, action); err != nil {
+			return state, err
+		}
+	}
+
+	return state, state.IsCompleteWith(command.Measure(program))
+}
@@ -1,15 +1,18 @@
 package core
 
 import (
+	"errors"
+	"slices"
 	"testing"
 
+	"github.com/hkupty/mirkwood/pkg/catalog"
 	"github.com/hkupty/mirkwood/pkg/command"
 	"github.com/hkupty/mirkwood/pkg/maze"
 )
 
 // internal helper to advance through the steps.
 // Returns the last processed state to aid debugging
-func process(state State, actions []any) (State, error) {
+func process(state State, actions []command.Action) (State, error) {
 	var stateCursor State
 	var err error
 	stateCursor = state
@@ -19,15 +22,11 @@ func process(state State, actions []any) (State, error) {
 			return stateCursor, err
 		}
 		if stateCursor.IsAtFinish() {
-			return stateCursor, nil
+			break
 		}
 	}
 
-	if !stateCursor.IsAtFinish() {
-		return stateCursor, ErrIncompletePath
-	}
-
-	return stateCursor, nil
+	return stateCursor, stateCursor.IsComplete()
 }
 
 func stateFromBoard(invariant LevelInvariants) State {
@@ -36,13 +35,28 @@ func stateFromBoard(invariant LevelInvariants) State {
 	}
 }
 
-func TestEngine(t *testing.T) {
+// sampleState places the player at the entrance of maze.SampleMaze
+func sampleState(t testing.TB) State {
+	walls, err := maze.GridToBitBoard(maze.SampleMaze)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	base := stateFromBoard(LevelInvariants{
-		Walls:          maze.BitBoard(0xFF2DA5B5B195C5FD),
-		FinishingPoint: maze.BitBoard(1 << 55),
+		Walls:          walls,
+		FinishingPoint: walls.Empty().Set(55),
+		Edges:          maze.NewEdges(walls),
 	})
 
-	base.Position = 1 << 1
+	base.Position = walls.Empty().Set(1)
+	base.VisitedPath = base.Position
+	base.Marks = walls.Empty()
+
+	return base
+}
+
+func TestEngine(t *testing.T) {
+	base := sampleState(t)
 
 	final, err := process(base, stringToCommandList("ssseenneesesssse"))
 
@@ -50,7 +64,7 @@ func TestEngine(t *testing.T) {
 		t.Error(err)
 	}
 	if !final.IsAtFinish() {
-		t.Fatalf("\nPOS    %b\nFINISH %b\n\n Not at the end", final.Position, final.Invariants.FinishingPoint)
+		t.Fatalf("\nPOS    %v\nFINISH %v\n\n Not at the end", final.Position, final.Invariants.FinishingPoint)
 	}
 
 	if err = final.IsValid(); err != nil {
@@ -58,10 +72,76 @@ func TestEngine(t *testing.T) {
 	}
 }
 
-func stringToCommandList(str string) []any {
+func TestEngineUnfolded(t *testing.T) {
+	program, err := command.Parse(`
+		repetir 3 { ↓ }
+		repetir 2 { → }
+		repetir 2 { ↑ }
+		repetir 2 { → }
+		↓ →
+		repetir 4 { ↓ }
+		→`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actions, err := command.Unfold(program, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The loops unfold to the same path TestEngine walks
+	if expected := stringToCommandList("ssseenneesesssse"); !slices.Equal(actions, expected) {
+		t.Fatalf("expected %v, got %v", expected, actions)
+	}
+
+	final, err := process(sampleState(t), actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !final.IsAtFinish() {
+		t.Fatal("expected the unfolded program to reach the finish")
+	}
+}
+
+func TestWinCondition(t *testing.T) {
+	path := "ssseenneesesssse"
+
+	cases := []struct {
+		name     string
+		win      maze.WinCondition
+		commands string
+		err      error
+	}{
+		{"simple exit", maze.SimpleExit, path, nil},
+		{"missing marks", maze.WinCondition{RequiredMarks: 2}, "m" + path, ErrMissingMarks},
+		{"enough marks", maze.WinCondition{RequiredMarks: 2}, "msm" + path[1:], nil},
+		{"within step limit", maze.WinCondition{MaxSteps: uint16(len(path))}, path, nil},
+		{"over step limit", maze.WinCondition{MaxSteps: 10}, path, ErrStepLimit},
+		{"incomplete", maze.SimpleExit, "sss", ErrIncompletePath},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			base := sampleState(t)
+			base.Invariants.WinCondition = tc.win
+
+			final, err := process(base, stringToCommandList(tc.commands))
+			if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			if limit := tc.win.MaxSteps; limit != 0 && final.StepsCounter > limit {
+				t.Fatalf("took %d steps with a limit of %d", final.StepsCounter, limit)
+			}
+		})
+	}
+}
+
+func stringToCommandList(str string) []command.Action {
 	// NOTE: This is an internal helper for testing, motivated by the fact
 	// fuzz testing can only take primitive types.
-	commands := make([]any, 0, len(str))
+	commands := make([]command.Action, 0, len(str))
 	for _, char := range str {
 		switch char {
 		case 's':
@@ -74,6 +154,12 @@ func stringToCommandList(str string) []any {
 			commands = append(commands, command.Walk{Dir: command.West})
 		case 'm':
 			commands = append(commands, command.Mark{})
+		case 'f':
+			commands = append(commands, command.Forward{})
+		case 'l':
+			commands = append(commands, command.Turn{Rot: command.Left})
+		case 'r':
+			commands = append(commands, command.Turn{Rot: command.Right})
 		}
 	}
 
@@ -81,12 +167,7 @@ func stringToCommandList(str string) []any {
 }
 
 func FuzzEngine(f *testing.F) {
-	base := stateFromBoard(LevelInvariants{
-		Walls:          maze.BitBoard(0xFF2DA5B5B195C5FD),
-		FinishingPoint: maze.BitBoard(1 << 55),
-	})
-
-	base.Position = 1 << 1
+	base := sampleState(f)
 
 	f.Add("s")
 	f.Add("n")
@@ -98,6 +179,8 @@ func FuzzEngine(f *testing.F) {
 	f.Add("ws")
 	f.Add("em")
 	f.Add("men")
+	f.Add("rff")
+	f.Add("lfrf")
 	f.Fuzz(func(t *testing.T, a string) {
 		state, _ := process(base, stringToCommandList(a))
 		// TODO: Further verify that errors match errored states.
@@ -107,3 +190,52 @@ func FuzzEngine(f *testing.F) {
 		}
 	})
 }
+
+func TestStepCost(t *testing.T) {
+	base := sampleState(t)
+
+	final, err := process(base, stringToCommandList("rrmsmf"))
+	if !errors.Is(err, ErrIncompletePath) {
+		t.Fatal(err)
+	}
+
+	// Only walking takes steps; turning and marking are done in place
+	if final.StepsCounter != 2 {
+		t.Fatalf("expected 2 steps, got %d", final.StepsCounter)
+	}
+}
+
+func TestRunCapsProgramSize(t *testing.T) {
+	// 13 blocks walking the sample maze out
+	program, err := command.Parse(`
+		repetir 3 { ↓ }
+		repetir 2 { → }
+		repetir 2 { ↑ }
+		repetir 2 { → }
+		↓ →
+		repetir 4 { ↓ }
+		→`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bp := maze.SampleBlueprint
+	bp.WinCondition.MaxBlocks = 13
+	state, err := NewStateFromBlueprint(bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(state, program, command.DefaultLimits); err != nil {
+		t.Fatalf("expected a program as large as allowed to complete the level, got %v", err)
+	}
+
+	// A larger program still walks out, but doesn't complete the level
+	state.Invariants.WinCondition.MaxBlocks = 12
+	final, err := Run(state, program, command.DefaultLimits)
+	if !final.IsAtFinish() || !errors.Is(err, ErrTooManyBlocks) {
+		t.Fatalf("expected the program to reach the finish with too many blocks, got %v", err)
+	}
+	if expected := "the program has more blocks than allowed: 13 of 12"; catalog.English.Render(err) != expected {
+		t.Fatalf("expected %q, got %q", expected, catalog.English.Render(err))
+	}
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
+
+// run parses src and drives the interpreter against state, the same way the TUI does.
+// Returns the last processed state to aid debugging
+func run(t testing.TB, state State, src string) (State, error) {
+	program, err := command.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interpreter := command.NewInterpreter(program, command.DefaultLimits)
+	for !state.IsAtFinish() {
+		action, done, err := interpreter.Next(state.Sensors())
+		if err != nil {
+			return state, err
+		}
+		if done {
+			break
+		}
+
+		state, err = Step(state, action)
+		if err != nil {
+			return state, err
+		}
+	}
+
+	return state, state.IsComplete()
+}
+
+func TestInterpreterFollowsWalls(t *testing.T) {
+	state, err := NewStateFromBlueprint(maze.SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keeping a hand on the wall to the right always leads to the exit
+	final, err := run(t, state, `
+		repetir 100 {
+			se parede_direita {
+				se parede { virar ← } senão { andar }
+			} senão {
+				virar →
+				andar
+			}
+		}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := final.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterpreterWhileFollowsWalls(t *testing.T) {
+	state, err := NewStateFromBlueprint(maze.SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	final, err := run(t, state, `
+		enquanto não fim {
+			se não parede_direita {
+				virar →
+				andar
+			} senão {
+				se parede { virar ← } senão { andar }
+			}
+		}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !final.IsAtFinish() {
+		t.Fatal("expected the loop to stop at the finish")
+	}
+}
+
+func TestInterpreterRecursion(t *testing.T) {
+	state, err := NewStateFromBlueprint(maze.SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same wall follower, written as a function that calls itself until the exit
+	final, err := run(t, state, `
+		função seguir {
+			se não fim {
+				se não parede_direita {
+					virar →
+					andar
+				} senão {
+					se parede { virar ← } senão { andar }
+				}
+				seguir
+			}
+		}
+		seguir`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !final.IsAtFinish() {
+		t.Fatal("expected the recursion to stop at the finish")
+	}
+}
+
+func TestInterpreterInfiniteLoop(t *testing.T) {
+	state, err := NewStateFromBlueprint(maze.SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Going back and forth ends every pass where it started
+	_, err = run(t, state, "↓\nenquanto não fim { ↓ ↑ }")
+	if !errors.Is(err, command.ErrInfiniteLoop) {
+		t.Fatalf("expected an infinite loop, got %v", err)
+	}
+
+	var runtimeErr *command.RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Span().Start.Line != 2 {
+		t.Fatalf("expected the loop at line 2 to be blamed, got %v", err)
+	}
+}
+
+// runner is what both the command.Interpreter and the command.VM do
+type runner interface {
+	Next(sensors command.Sensors) (command.Action, bool, error)
+}
+
+// trace drives r against state like run does, collecting up to limit actions
+func trace(state State, r runner, limit int) ([]command.Action, error) {
+	var actions []command.Action
+	for len(actions) < limit && !state.IsAtFinish() {
+		action, done, err := r.Next(state.Sensors())
+		if err != nil || done {
+			return actions, err
+		}
+
+		actions = append(actions, action)
+		if state, err = Step(state, action); err != nil {
+			return actions, err
+		}
+	}
+	return actions, nil
+}
+
+func FuzzVM(f *testing.F) {
+	f.Add("enquanto não fim { se não parede_direita { virar → andar } senão { se parede { virar ← } senão { andar } } }")
+	f.Add("função f { se não parede ↓ { ↓ f } } f → repetir 2 { marcar se marcado { ↑ } }")
+	f.Add("↓ enquanto não fim { ↓ ↑ }")
+	f.Fuzz(func(t *testing.T, src string) {
+		program, err := command.Parse(src)
+		if err != nil {
+			return
+		}
+
+		code, err := command.Compile(program)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		state, err := NewStateFromBlueprint(maze.SampleBlueprint)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Budgets are spent differently, so only what both did before either ran out is compared
+		limits := command.Limits{Budget: 10_000, MaxDepth: 50}
+		expected, expectedErr := trace(state, command.NewInterpreter(program, limits), 300)
+		actions, err := trace(state, command.NewVM(code, limits), 300)
+		if errors.Is(expectedErr, command.ErrOutOfBudget) || errors.Is(err, command.ErrOutOfBudget) {
+			size := min(len(expected), len(actions))
+			expected, actions = expected[:size], actions[:size]
+			expectedErr, err = nil, nil
+		}
+
+		if !slices.Equal(expected, actions) {
+			t.Fatalf("interpreter did %v, but the VM did %v", expected, actions)
+		}
+		if fmt.Sprint(expectedErr) != fmt.Sprint(err) {
+			t.Fatalf("interpreter failed with %v, but the VM failed with %v", expectedErr, err)
+		}
+	})
+}
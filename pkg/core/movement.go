@@ -2,78 +2,125 @@ package core
 
 import (
 	"errors"
-	"math/bits"
 
+	"github.com/hkupty/mirkwood/pkg/catalog"
 	"github.com/hkupty/mirkwood/pkg/command"
 	"github.com/hkupty/mirkwood/pkg/maze"
 )
 
 var (
 	// ErrInvalidState indicates the state has become corrupted (e.g., multiple positions)
-	ErrInvalidState = errors.New("invalid game state")
+	ErrInvalidState = catalog.New("core.invalid_state")
 
 	// ErrInvalidAction indicates that this action would cause the state to be invalid
-	ErrInvalidAction = errors.New("action would cause the state to be invalid")
+	ErrInvalidAction = catalog.New("core.invalid_action")
 
 	// ErrHitWall indicates the player attempted to move into a wall
-	ErrHitWall = errors.New("you hit a tree")
+	ErrHitWall = catalog.New("core.hit_wall")
+
+	// ErrOutOfBounds indicates the player attempted to walk off the edge of the board
+	ErrOutOfBounds = catalog.New("core.out_of_bounds")
 
 	// ErrStepLimit indicates the player exceeded the maximum step count
-	ErrStepLimit = errors.New("step limit exceeded")
+	ErrStepLimit = catalog.New("core.step_limit")
+
+	// ErrIncompletePath indicates the program ended before reaching the finishing point
+	ErrIncompletePath = catalog.New("core.incomplete_path")
+
+	// ErrMissingMarks indicates the finishing point was reached with fewer marks than required
+	ErrMissingMarks = catalog.New("core.missing_marks")
 
-	// ErrAlreadyComplete indicates the level is already finished
-	ErrIncompletePath = errors.New("logic hit an end but did not reach the end of the maze")
+	// ErrTooManyBlocks indicates the finishing point was reached by a program larger than allowed
+	ErrTooManyBlocks = catalog.New("core.too_many_blocks")
 )
 
-// Move attempts to move the player in the given direction.
+// Move attempts to move the player in the given direction, facing it, and counts the step.
+// The step limit is only checked by Step and IsComplete, so Move alone can go past it.
 // Returns the new state and an error if the move is invalid.
 func (s State) Move(dir command.Direction) (State, error) {
 	// Verify we have exactly one position bit set
-	if bits.OnesCount64(uint64(s.Position)) != 1 {
+	if s.Position.Count() != 1 {
 		return s, ErrInvalidState
 	}
 
-	// Calculate next position based on direction
-	var nextPos maze.BitBoard
-	switch dir {
-	case command.North:
-		if s.Position < (1 << 8) {
-			return s, ErrInvalidAction
-		}
-		nextPos = s.Position >> 8
-	case command.South:
-		if s.Position > (1 << 56) {
-			return s, ErrInvalidAction
-		}
-		nextPos = s.Position << 8
-	case command.East:
-		nextPos = s.Position << 1
-	case command.West:
-		nextPos = s.Position >> 1
+	nextPos, err := s.neighbour(dir)
+	if err != nil {
+		return s, err
 	}
 
 	// Check for wall collision
-	if nextPos&s.Invariants.Walls != 0 {
+	if nextPos.Intersects(s.Invariants.Walls) {
 		return s, ErrHitWall
 	}
 
 	// Create new state (immutable update)
 	return State{
 		Position:     nextPos,
-		VisitedPath:  s.VisitedPath | nextPos,
+		Heading:      dir,
+		VisitedPath:  s.VisitedPath.Or(nextPos),
 		Marks:        s.Marks,
 		StepsCounter: s.StepsCounter + 1,
 		Invariants:   s.Invariants,
 	}, nil
 }
 
+// neighbour calculates the position next to the player in the given direction,
+// stopping at the edge of the board. Walls are not considered.
+// Returns ErrInvalidState when the edges of the board were never computed (see maze.NewEdges).
+func (s State) neighbour(dir command.Direction) (maze.BitBoard, error) {
+	var nextPos, edge maze.BitBoard
+
+	// Without edges nothing would stop moves from wrapping into the next row
+	if s.Invariants.Edges.IsZero() {
+		return nextPos, ErrInvalidState
+	}
+
+	row := uint16(s.Position.Width())
+	switch dir {
+	case command.North:
+		edge = s.Invariants.Edges.North
+		nextPos = s.Position.Shr(row)
+	case command.South:
+		edge = s.Invariants.Edges.South
+		nextPos = s.Position.Shl(row)
+	case command.East:
+		edge = s.Invariants.Edges.East
+		nextPos = s.Position.Shl(1)
+	case command.West:
+		edge = s.Invariants.Edges.West
+		nextPos = s.Position.Shr(1)
+	default:
+		return nextPos, ErrInvalidAction
+	}
+
+	if s.Position.Intersects(edge) {
+		return nextPos, ErrOutOfBounds
+	}
+
+	return nextPos, nil
+}
+
 // ToggleMark toggles a mark on the current position.
 // Returns the new state with the mark toggled.
 func (s State) ToggleMark() State {
 	return State{
 		Position:     s.Position,
+		Heading:      s.Heading,
 		VisitedPath:  s.VisitedPath,
-		Marks:        s.Marks ^ s.Position,
+		Marks:        s.Marks.Xor(s.Position),
+		StepsCounter: s.StepsCounter,
+		Invariants:   s.Invariants,
+	}
+}
+
+// Turn rotates the player's heading without moving.
+// Returns the new state with the heading changed.
+func (s State) Turn(rot command.Rotation) State {
+	return State{
+		Position:     s.Position,
+		Heading:      s.Heading.Turn(rot),
+		VisitedPath:  s.VisitedPath,
+		Marks:        s.Marks,
 		StepsCounter: s.StepsCounter,
 		Invariants:   s.Invariants,
 	}
@@ -81,33 +128,70 @@ func (s State) ToggleMark() State {
 
 // IsAtFinish returns true if the player is at the finishing point
 func (s State) IsAtFinish() bool {
-	return s.Position&s.Invariants.FinishingPoint != 0
+	return s.Position.Intersects(s.Invariants.FinishingPoint)
+}
+
+// IsComplete checks the state against the level's win condition.
+// Returns nil if the level is complete, otherwise an error for each requirement that failed.
+func (s State) IsComplete() error {
+	var errs []error
+	win := s.Invariants.WinCondition
+
+	if !s.IsAtFinish() {
+		errs = append(errs, ErrIncompletePath)
+	}
+
+	if marks := s.MarkCount(); marks < int(win.RequiredMarks) {
+		errs = append(errs, ErrMissingMarks.Detail("core.missing_marks_count", marks, win.RequiredMarks))
+	}
+
+	if s.exceedsStepLimit() {
+		errs = append(errs, ErrStepLimit.Detail("core.step_limit_count", s.StepsCounter, win.MaxSteps))
+	}
+
+	return errors.Join(errs...)
+}
+
+// IsCompleteWith checks the state reached by a program of the given size against the level's win condition.
+// On top of what IsComplete checks, the program must not be larger than the win condition's MaxBlocks.
+func (s State) IsCompleteWith(metrics command.Metrics) error {
+	err := s.IsComplete()
+	if limit := s.Invariants.WinCondition.MaxBlocks; limit != 0 && metrics.Blocks > int(limit) {
+		err = errors.Join(err, ErrTooManyBlocks.Detail("core.too_many_blocks_count", metrics.Blocks, limit))
+	}
+	return err
+}
+
+// exceedsStepLimit reports whether more steps were taken than the win condition allows.
+func (s State) exceedsStepLimit() bool {
+	limit := s.Invariants.WinCondition.MaxSteps
+	return limit != 0 && s.StepsCounter > limit
 }
 
 // MarkCount returns the number of marked cells
 func (s State) MarkCount() int {
-	return bits.OnesCount64(uint64(s.Marks))
+	return s.Marks.Count()
 }
 
 // IsValid validates that the state satisfies all invariants
 func (s State) IsValid() error {
 	// Check exactly one position
-	if bits.OnesCount64(uint64(s.Position)) != 1 {
+	if s.Position.Count() != 1 {
 		return ErrInvalidState
 	}
 
 	// Check no overlap between marks and walls
-	if s.Marks&s.Invariants.Walls != 0 {
+	if s.Marks.Intersects(s.Invariants.Walls) {
 		return ErrInvalidState
 	}
 
 	// Check no overlap between visited path and walls
-	if s.VisitedPath&s.Invariants.Walls != 0 {
+	if s.VisitedPath.Intersects(s.Invariants.Walls) {
 		return ErrInvalidState
 	}
 
 	// Check position is not on a wall
-	if s.Position&s.Invariants.Walls != 0 {
+	if s.Position.Intersects(s.Invariants.Walls) {
 		return ErrInvalidState
 	}
 
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/hkupty/mirkwood/pkg/catalog"
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
+
+func TestMoveStopsAtEdges(t *testing.T) {
+	src, err := os.ReadFile("../../mazes/01.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bp, err := maze.ParseLevel(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := NewStateFromBlueprint(bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The entrance is an opening in the northern border
+	if _, err := state.Move(command.North); !errors.Is(err, ErrOutOfBounds) {
+		t.Fatalf("expected %v walking out of the entrance, got %v", ErrOutOfBounds, err)
+	}
+
+	// The exit is an opening in the eastern border
+	state.Position = state.Invariants.FinishingPoint
+	if _, err := state.Move(command.East); !errors.Is(err, ErrOutOfBounds) {
+		t.Fatalf("expected %v walking out of the exit, got %v", ErrOutOfBounds, err)
+	}
+}
+
+func TestMoveDoesNotWrapRows(t *testing.T) {
+	// Walking east from the exit of the sample maze used to land on the first column of the next row
+	state := sampleState(t)
+	state.Position = state.Invariants.FinishingPoint
+
+	if _, err := state.Move(command.East); !errors.Is(err, ErrOutOfBounds) {
+		t.Fatalf("expected %v, got %v", ErrOutOfBounds, err)
+	}
+}
+
+func TestMoveNeedsEdges(t *testing.T) {
+	// Invariants built by hand without maze.NewEdges would let moves wrap around rows
+	state := sampleState(t)
+	state.Position = state.Invariants.FinishingPoint
+	state.Invariants.Edges = maze.Edges{}
+
+	if _, err := state.Move(command.East); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected %v, got %v", ErrInvalidState, err)
+	}
+	if !state.WallTowards(command.East) {
+		t.Error("expected sensors to treat the missing edges as blocked")
+	}
+}
+
+func TestMoveCountsSteps(t *testing.T) {
+	state := sampleState(t)
+
+	moved, err := state.Move(command.South)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.StepsCounter != 1 {
+		t.Fatalf("expected Move to count a step, got %d", moved.StepsCounter)
+	}
+
+	// Step charges the cost of the action instead, without counting the move twice
+	stepped, err := Step(state, command.Walk{Dir: command.South})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stepped.StepsCounter != 1 {
+		t.Fatalf("expected Step to count a step, got %d", stepped.StepsCounter)
+	}
+}
+
+func FuzzMoveEdges(f *testing.F) {
+	// A board without walls, so only the edges constrain movement
+	grid := make(maze.MazeGrid, 5)
+	for ix := range grid {
+		grid[ix] = make([]bool, 7)
+	}
+
+	base, err := NewStateFromBlueprint(maze.LevelBlueprint{
+		Grid:           grid,
+		StartingPoint:  0,
+		FinishingPoint: 34,
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add("eeeeeeeee")
+	f.Add("wwww")
+	f.Add("nnnn")
+	f.Add("sssssssss")
+	f.Add("seseseseseseseses")
+	f.Add("eeeeeeesw")
+	f.Fuzz(func(t *testing.T, a string) {
+		state := base
+		for _, action := range stringToCommandList(a) {
+			walk, ok := action.(command.Walk)
+			if !ok {
+				continue
+			}
+
+			next, err := state.Move(walk.Dir)
+			if err != nil && !errors.Is(err, ErrOutOfBounds) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if err != nil && next.Position != state.Position {
+				t.Fatal("position changed after leaving the board")
+			}
+
+			before, _ := state.Position.Lowest()
+			after, _ := next.Position.Lowest()
+			fromRow, fromCol := state.Position.Pos(before)
+			toRow, toCol := next.Position.Pos(after)
+
+			if distance(fromCol, toCol)+distance(fromRow, toRow) > 1 {
+				t.Fatalf("moving %v jumped from %d:%d to %d:%d", walk.Dir, fromRow, fromCol, toRow, toCol)
+			}
+
+			if err := next.IsValid(); err != nil {
+				t.Fatal(err)
+			}
+
+			state = next
+		}
+	})
+}
+
+func distance(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestTurnAndForward(t *testing.T) {
+	state, err := NewStateFromBlueprint(maze.SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The sample maze is entered from the north, so the player starts facing south
+	if state.Heading != command.South {
+		t.Fatalf("expected to face south, got %v", state.Heading)
+	}
+
+	// Same path as TestEngine, but walking like a turtle
+	final, err := process(state, stringToCommandList("ffflfflffrffrflfrfffflf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if final.Heading != command.East {
+		t.Fatalf("expected to face east at the exit, got %v", final.Heading)
+	}
+
+	for _, dir := range []command.Direction{command.North, command.South, command.East, command.West} {
+		spun := dir
+		for range 4 {
+			spun = spun.Turn(command.Right)
+		}
+		if spun != dir || dir.Turn(command.Left).Turn(command.Right) != dir {
+			t.Fatalf("turning around doesn't return to %v", dir)
+		}
+	}
+}
+
+func TestIsCompleteIsLocalized(t *testing.T) {
+	state, err := NewStateFromBlueprint(maze.SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Invariants.WinCondition.RequiredMarks = 2
+
+	err = state.IsComplete()
+	if !errors.Is(err, ErrIncompletePath) || !errors.Is(err, ErrMissingMarks) {
+		t.Fatalf("expected the path and the marks to be missing, got %v", err)
+	}
+
+	expected := "o programa terminou antes de sair da floresta\nfaltou marcar algumas casas: 0 de 2"
+	if text := catalog.Portuguese.Render(err); text != expected {
+		t.Fatalf("expected %q, got %q", expected, text)
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/hkupty/mirkwood/pkg/catalog"
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
+
+// MaxStars is the rating of a solution that is as short as the level's par, or shorter.
+const MaxStars = 3
+
+// Rating scores a program that completed a level, rewarding shorter programs
+// (such as loops instead of long arrow chains) with more stars.
+type Rating struct {
+	Metrics command.Metrics
+
+	// Par is the size of a good solution in blocks, or 0 when the level has none
+	Par int
+
+	// Stars goes from 1 (it works) to MaxStars (it's as short as the par)
+	Stars int
+}
+
+// Rate scores a program that completed a level with the given win condition.
+// Programs up to the par get every star, up to half as long again get two, and anything longer gets one.
+func Rate(win maze.WinCondition, metrics command.Metrics) Rating {
+	rating := Rating{Metrics: metrics, Par: int(win.ParBlocks), Stars: MaxStars}
+
+	switch par := rating.Par; {
+	case par == 0 || metrics.Blocks <= par:
+	case metrics.Blocks <= par+par/2:
+		rating.Stars = 2
+	default:
+		rating.Stars = 1
+	}
+
+	return rating
+}
+
+// Localize writes the stars along with the size of the program, and the par when there is one, in the language of c.
+func (r Rating) Localize(c *catalog.Catalog) string {
+	stars := strings.Repeat("★", r.Stars) + strings.Repeat("☆", MaxStars-r.Stars)
+	if r.Par == 0 {
+		return c.Text("core.rating", stars, r.Metrics.Blocks)
+	}
+	return c.Text("core.rating_par", stars, r.Metrics.Blocks, r.Par)
+}
+
+// String writes the rating in English, the way errors are written for developers.
+func (r Rating) String() string {
+	return r.Localize(catalog.English)
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/hkupty/mirkwood/pkg/catalog"
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
+
+func TestRate(t *testing.T) {
+	cases := []struct {
+		par    uint16
+		blocks int
+		stars  int
+	}{
+		{0, 100, 3},
+		{4, 3, 3},
+		{4, 4, 3},
+		{4, 6, 2},
+		{4, 7, 1},
+		{1, 2, 1},
+	}
+
+	for _, tc := range cases {
+		rating := Rate(maze.WinCondition{ParBlocks: tc.par}, command.Metrics{Blocks: tc.blocks})
+		if rating.Stars != tc.stars {
+			t.Errorf("par %d with %d blocks: expected %d stars, got %v", tc.par, tc.blocks, tc.stars, rating)
+		}
+	}
+}
+
+func TestRatingIsLocalized(t *testing.T) {
+	rating := Rate(maze.WinCondition{ParBlocks: 3}, command.Metrics{Blocks: 5})
+
+	if text := rating.Localize(catalog.Portuguese); text != "★☆☆ 5 blocos (meta 3)" {
+		t.Fatalf("expected the rating in Portuguese, got %q", text)
+	}
+	if text := rating.String(); text != "★☆☆ 5 blocks (par 3)" {
+		t.Fatalf("expected developers to read the rating in English, got %q", text)
+	}
+}
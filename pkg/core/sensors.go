@@ -0,0 +1,75 @@
+package core
+
+import (
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
+
+// WallTowards reports whether the cell next to the player in the given direction is blocked,
+// either by a wall or by the edge of the board.
+func (s State) WallTowards(dir command.Direction) bool {
+	next, err := s.neighbour(dir)
+	return err != nil || next.Intersects(s.Invariants.Walls)
+}
+
+// WallAhead reports whether the cell the player is facing is blocked.
+func (s State) WallAhead() bool {
+	return s.WallTowards(s.Heading)
+}
+
+// WallLeft reports whether the cell to the left of the player is blocked.
+func (s State) WallLeft() bool {
+	return s.WallTowards(s.Heading.Turn(command.Left))
+}
+
+// WallRight reports whether the cell to the right of the player is blocked.
+func (s State) WallRight() bool {
+	return s.WallTowards(s.Heading.Turn(command.Right))
+}
+
+// IsMarked reports whether the player is standing on a marked cell.
+func (s State) IsMarked() bool {
+	return s.Position.Intersects(s.Marks)
+}
+
+// VisitedTowards reports whether the cell next to the player in the given direction was stepped on before.
+// NOTE: This is engine-only knowledge; players are expected to leave marks to remember where they have been,
+// so it is intentionally not part of command.Sensors.
+func (s State) VisitedTowards(dir command.Direction) bool {
+	next, err := s.neighbour(dir)
+	return err == nil && next.Intersects(s.VisitedPath)
+}
+
+// Sensors exposes the state to player programs only through command.Sensors,
+// so the interpreter can query the world around the player without reaching into the engine.
+func (s State) Sensors() command.Sensors {
+	return playerSensors{state: s}
+}
+
+// playerSensors is the restricted view of a State handed to player programs.
+type playerSensors struct {
+	state State
+}
+
+func (p playerSensors) WallAhead() bool                 { return p.state.WallAhead() }
+func (p playerSensors) WallLeft() bool                  { return p.state.WallLeft() }
+func (p playerSensors) WallRight() bool                 { return p.state.WallRight() }
+func (p playerSensors) Wall(dir command.Direction) bool { return p.state.WallTowards(dir) }
+func (p playerSensors) IsMarked() bool                  { return p.state.IsMarked() }
+func (p playerSensors) AtFinish() bool                  { return p.state.IsAtFinish() }
+
+// Fingerprint covers what the player can sense: where they are, where they face and what they marked.
+func (p playerSensors) Fingerprint() any {
+	return sensedWorld{
+		position: p.state.Position,
+		heading:  p.state.Heading,
+		marks:    p.state.Marks,
+	}
+}
+
+// sensedWorld is the part of a State that can change while a program runs and be told apart by sensors.
+type sensedWorld struct {
+	position maze.BitBoard
+	heading  command.Direction
+	marks    maze.BitBoard
+}
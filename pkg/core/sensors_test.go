@@ -0,0 +1,74 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
+
+func TestSensors(t *testing.T) {
+	state, err := NewStateFromBlueprint(maze.SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// At the entrance, facing south into the maze with trees on both sides
+	sensors := state.Sensors()
+	if sensors.WallAhead() {
+		t.Error("expected the way ahead to be open")
+	}
+	if !sensors.WallLeft() || !sensors.WallRight() {
+		t.Error("expected trees on both sides")
+	}
+	if !sensors.Wall(command.North) {
+		t.Error("expected the edge of the board to count as a wall")
+	}
+	if sensors.IsMarked() || sensors.AtFinish() {
+		t.Error("expected an unmarked cell away from the finish")
+	}
+
+	if !state.ToggleMark().Sensors().IsMarked() {
+		t.Error("expected the cell to be marked")
+	}
+
+	next, err := state.Move(command.South)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.VisitedTowards(command.North) || next.VisitedTowards(command.South) {
+		t.Error("expected only the entrance to be visited")
+	}
+}
+
+func FuzzSensors(f *testing.F) {
+	base := sampleState(f)
+
+	f.Add("s")
+	f.Add("ssse")
+	f.Add("lfrfm")
+	f.Add("ssseennee")
+	f.Fuzz(func(t *testing.T, a string) {
+		state := base
+		for _, action := range stringToCommandList(a) {
+			next, err := Step(state, action)
+			if err != nil {
+				continue
+			}
+			state = next
+
+			// Sensing a wall must agree with being unable to walk there
+			for _, dir := range []command.Direction{command.North, command.South, command.East, command.West} {
+				_, err := state.Move(dir)
+				if state.WallTowards(dir) != (err != nil) {
+					t.Fatalf("sensed wall %v towards %v, but moving returned %v", state.WallTowards(dir), dir, err)
+				}
+			}
+
+			_, err = Step(state, command.Forward{})
+			if state.Sensors().WallAhead() != (err != nil) {
+				t.Fatalf("sensed wall ahead %v, but moving forward returned %v", state.WallAhead(), err)
+			}
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package core
 
-import "github.com/hkupty/mirkwood/pkg/maze"
+import (
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
 
 // State represents the runtime state of a level.
 // It tracks the player's position, visited cells, marks, and step count.
@@ -8,6 +11,9 @@ type State struct {
 	// Position is a single-bit bitboard indicating where the player is
 	Position maze.BitBoard
 
+	// Heading is the direction the player is facing
+	Heading command.Direction
+
 	// VisitedPath tracks all cells the player has stepped on
 	VisitedPath maze.BitBoard
 
@@ -28,4 +34,10 @@ type LevelInvariants struct {
 
 	// FinishingPoint is the target position to reach
 	FinishingPoint maze.BitBoard
+
+	// Edges are the borders of the board, which can't be walked past
+	Edges maze.Edges
+
+	// WinCondition defines what must be satisfied, besides reaching the finish, to complete the level
+	WinCondition maze.WinCondition
 }
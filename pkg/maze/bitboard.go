@@ -0,0 +1,220 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// boardWords is how many integers back a single BitBoard.
+const boardWords = 16
+
+// MaxCells is the number of cells a BitBoard can hold, e.g. a 32x32 board.
+const MaxCells = boardWords * 64
+
+// ErrEmptyBoard indicates a board without any cells
+var ErrEmptyBoard = errors.New("board has no cells")
+
+// BitBoard is a (max) MaxCells board linearized into a fixed array of integers for cache friendliness.
+// The cell at row, col is the bit row*width + col, so the bitwise operations of a single integer
+// (shifts, masks) still apply, only carried over between the words.
+// Bits past the last cell are always zero.
+// It can represent walls (1 = wall), visited paths, or marked cells.
+//
+// Boards are values: every operation returns a new BitBoard and they can be compared with ==.
+// Operations between two boards expect both to have the same dimensions.
+type BitBoard struct {
+	width  uint8
+	height uint8
+	words  [boardWords]uint64
+}
+
+// NewBitBoard creates an empty board with the given dimensions.
+func NewBitBoard(width, height int) (BitBoard, error) {
+	if err := checkSize(width, height); err != nil {
+		return BitBoard{}, err
+	}
+	return BitBoard{width: uint8(width), height: uint8(height)}, nil
+}
+
+// checkSize verifies a board with the given dimensions can be represented by a BitBoard.
+func checkSize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("%w: %dx%d", ErrEmptyBoard, width, height)
+	}
+	if width > math.MaxUint8 || height > math.MaxUint8 || width*height > MaxCells {
+		return fmt.Errorf("%w: %dx%d", ErrLevelTooLarge, width, height)
+	}
+	return nil
+}
+
+// Width is the number of columns in the board
+func (b BitBoard) Width() uint8 {
+	return b.width
+}
+
+// Height is the number of rows in the board
+func (b BitBoard) Height() uint8 {
+	return b.height
+}
+
+// Cells is the number of cells (and meaningful bits) in the board
+func (b BitBoard) Cells() uint16 {
+	return uint16(b.width) * uint16(b.height)
+}
+
+// Bit converts grid coordinates to a bit position in this board.
+func (b BitBoard) Bit(row, col uint8) uint16 {
+	return PosToBit(b.width, row, col)
+}
+
+// Pos converts a bit position in this board to grid coordinates.
+func (b BitBoard) Pos(bit uint16) (row, col uint8) {
+	return BitToPos(b.width, bit)
+}
+
+// Empty returns a board with the same dimensions and no bits set.
+func (b BitBoard) Empty() BitBoard {
+	return BitBoard{width: b.width, height: b.height}
+}
+
+// Full returns a board with the same dimensions and every cell set.
+func (b BitBoard) Full() BitBoard {
+	full := b.Empty()
+	for ix := range full.words {
+		full.words[ix] = math.MaxUint64
+	}
+	return full.clip()
+}
+
+// Set returns a copy of the board with the given bit set. Bits outside the board are ignored.
+func (b BitBoard) Set(bit uint16) BitBoard {
+	if bit < b.Cells() {
+		b.words[bit/64] |= 1 << (bit % 64)
+	}
+	return b
+}
+
+// Has reports whether the given bit is set.
+func (b BitBoard) Has(bit uint16) bool {
+	return bit < b.Cells() && b.words[bit/64]&(1<<(bit%64)) != 0
+}
+
+// And returns the cells set in both boards.
+func (b BitBoard) And(o BitBoard) BitBoard {
+	for ix := range b.words {
+		b.words[ix] &= o.words[ix]
+	}
+	return b
+}
+
+// Or returns the cells set in either board.
+func (b BitBoard) Or(o BitBoard) BitBoard {
+	for ix := range b.words {
+		b.words[ix] |= o.words[ix]
+	}
+	return b
+}
+
+// Xor returns the cells set in exactly one of the boards.
+func (b BitBoard) Xor(o BitBoard) BitBoard {
+	for ix := range b.words {
+		b.words[ix] ^= o.words[ix]
+	}
+	return b
+}
+
+// AndNot returns the cells set in b but not in o.
+func (b BitBoard) AndNot(o BitBoard) BitBoard {
+	for ix := range b.words {
+		b.words[ix] &^= o.words[ix]
+	}
+	return b
+}
+
+// Intersects reports whether both boards have any cell in common.
+func (b BitBoard) Intersects(o BitBoard) bool {
+	for ix := range b.words {
+		if b.words[ix]&o.words[ix] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsZero reports whether no cell is set.
+func (b BitBoard) IsZero() bool {
+	return b.words == [boardWords]uint64{}
+}
+
+// Count returns the number of cells set.
+func (b BitBoard) Count() int {
+	count := 0
+	for _, word := range b.words {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// Lowest returns the position of the first set bit, or false if the board is empty.
+// This is how a single-bit board (like a position) is converted back to a bit position.
+func (b BitBoard) Lowest() (uint16, bool) {
+	for ix, word := range b.words {
+		if word != 0 {
+			return uint16(ix*64 + bits.TrailingZeros64(word)), true
+		}
+	}
+	return 0, false
+}
+
+// Shl shifts every bit n positions up (towards the end of the board).
+// Bits shifted past the last cell are dropped.
+func (b BitBoard) Shl(n uint16) BitBoard {
+	wordShift := int(n / 64)
+	bitShift := n % 64
+	shifted := b.Empty()
+
+	for ix := boardWords - 1; ix >= wordShift; ix-- {
+		src := ix - wordShift
+		shifted.words[ix] = b.words[src] << bitShift
+		if bitShift != 0 && src > 0 {
+			shifted.words[ix] |= b.words[src-1] >> (64 - bitShift)
+		}
+	}
+
+	return shifted.clip()
+}
+
+// Shr shifts every bit n positions down (towards the start of the board).
+// Bits shifted before the first cell are dropped.
+func (b BitBoard) Shr(n uint16) BitBoard {
+	wordShift := int(n / 64)
+	bitShift := n % 64
+	shifted := b.Empty()
+
+	for ix := 0; ix+wordShift < boardWords; ix++ {
+		src := ix + wordShift
+		shifted.words[ix] = b.words[src] >> bitShift
+		if bitShift != 0 && src+1 < boardWords {
+			shifted.words[ix] |= b.words[src+1] << (64 - bitShift)
+		}
+	}
+
+	return shifted
+}
+
+// clip clears every bit past the last cell, keeping the board invariant.
+func (b BitBoard) clip() BitBoard {
+	cells := int(b.Cells())
+	for ix := range b.words {
+		switch {
+		case (ix+1)*64 <= cells:
+		case ix*64 >= cells:
+			b.words[ix] = 0
+		default:
+			b.words[ix] &= (1 << (cells % 64)) - 1
+		}
+	}
+	return b
+}
@@ -0,0 +1,79 @@
+package maze
+
+import (
+	"testing"
+)
+
+func TestGridToBitBoard(t *testing.T) {
+	board, err := GridToBitBoard(SampleMaze)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An 8x8 board still fits in the first word, matching the layout documented in model.go
+	if board.words[0] != 0xFF2DA5B5B195C5FD {
+		t.Fatalf("unexpected layout %x", board.words[0])
+	}
+
+	if board.Width() != 8 || board.Height() != 8 {
+		t.Fatalf("expected an 8x8 board, got %dx%d", board.Width(), board.Height())
+	}
+}
+
+// reference is a naive, cell-by-cell version of the board used to cross-check bitwise operations
+func reference(b BitBoard) []bool {
+	cells := make([]bool, b.Cells())
+	for ix := range cells {
+		cells[ix] = b.Has(uint16(ix))
+	}
+	return cells
+}
+
+func FuzzBitBoardShift(f *testing.F) {
+	f.Add(uint8(8), uint8(8), uint64(0xFF2DA5B5B195C5FD), uint64(0), uint16(8), true)
+	f.Add(uint8(9), uint8(13), uint64(0xF0F0F0F0F0F0F0F0), uint64(0xFFFF), uint16(9), false)
+	f.Add(uint8(32), uint8(32), uint64(1), uint64(1<<63), uint16(65), true)
+	f.Add(uint8(255), uint8(4), uint64(1<<63), uint64(3), uint16(255), false)
+
+	f.Fuzz(func(t *testing.T, width, height uint8, low, high uint64, n uint16, left bool) {
+		board, err := NewBitBoard(int(width), int(height))
+		if err != nil {
+			return
+		}
+
+		// Spread the fuzzed bits over the first and last words in use
+		cells := board.Cells()
+		for ix := range uint16(64) {
+			if low&(1<<ix) != 0 {
+				board = board.Set(ix)
+			}
+			if high&(1<<ix) != 0 && cells > 64 {
+				board = board.Set(cells - 64 + ix)
+			}
+		}
+
+		before := reference(board)
+		var shifted BitBoard
+		if left {
+			shifted = board.Shl(n)
+		} else {
+			shifted = board.Shr(n)
+		}
+		after := reference(shifted)
+
+		for ix := range after {
+			src := ix + int(n)
+			if left {
+				src = ix - int(n)
+			}
+			expected := src >= 0 && src < len(before) && before[src]
+			if after[ix] != expected {
+				t.Fatalf("bit %d: expected %v after shifting by %d", ix, expected, n)
+			}
+		}
+
+		if shifted.Count() > int(cells) || shifted.Full().Count() != int(cells) {
+			t.Fatal("bits set past the end of the board")
+		}
+	})
+}
@@ -2,27 +2,34 @@ package maze
 
 // GridToBitBoard converts a MazeGrid to a BitBoard representation.
 // Returns the bitboard where each bit represents a wall (1) or path (0).
-func GridToBitBoard(grid MazeGrid) BitBoard {
-	var bitBoard uint64
+func GridToBitBoard(grid MazeGrid) (BitBoard, error) {
+	width, height, err := grid.Size()
+	if err != nil {
+		return BitBoard{}, err
+	}
+
+	bitBoard, err := NewBitBoard(width, height)
+	if err != nil {
+		return BitBoard{}, err
+	}
 
-	// NOTE: Very important here that rows and columns are expected to always be 8x8
 	for jx, row := range grid {
 		for ix, col := range row {
 			if col {
-				bitBoard |= 1 << (8*jx + ix)
+				bitBoard = bitBoard.Set(bitBoard.Bit(uint8(jx), uint8(ix)))
 			}
 		}
 	}
 
-	return BitBoard(bitBoard)
+	return bitBoard, nil
 }
 
-// PosToBit converts grid coordinates to a bit position (0-63).
-func PosToBit(row, col uint8) uint8 {
-	return row*8 + col
+// PosToBit converts grid coordinates to a bit position in a board with the given width.
+func PosToBit(width, row, col uint8) uint16 {
+	return uint16(row)*uint16(width) + uint16(col)
 }
 
-// BitToPos converts a bit position to grid coordinates.
-func BitToPos(bit uint8) (row, col uint8) {
-	return bit / 8, bit % 8
+// BitToPos converts a bit position in a board with the given width to grid coordinates.
+func BitToPos(width uint8, bit uint16) (row, col uint8) {
+	return uint8(bit / uint16(width)), uint8(bit % uint16(width))
 }
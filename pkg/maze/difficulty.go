@@ -0,0 +1,307 @@
+package maze
+
+import "github.com/hkupty/mirkwood/pkg/command"
+
+// DifficultyReport describes what makes a level hard to solve, so levels can be sorted into a progression.
+// The path measures follow the shortest way from the start to the finish, as found by Solver.ShortestPath.
+type DifficultyReport struct {
+	// PathLength is how many steps the shortest path takes
+	PathLength int
+
+	// Turns is how many times the shortest path changes direction
+	Turns int
+
+	// LongestRun is the most steps the shortest path takes in the same direction in a row
+	LongestRun int
+
+	// DeadEnds counts the path cells with a single way out, start and finish aside
+	DeadEnds int
+
+	// Junctions counts the path cells where three or four ways meet, each one a choice to make
+	Junctions int
+
+	// Loops is how many independent loops the paths form, zero for a perfect maze.
+	// Open areas count as well, as walking around them leads back to where it started.
+	Loops int
+
+	// Blocks is the size of the smallest program walking the shortest path with the constructs the level allows:
+	// arrows or turning and moving forward, shortened with repeats nested up to MaxDepth and a function.
+	// Sensors, marks and more than one function aren't searched, so when the level has a par below it, the par is used instead.
+	// It is zero when the level allows no way of moving and has no par.
+	Blocks int
+}
+
+// Difficulty measures bp, which must be a valid level (see LevelBlueprint.Validate).
+func Difficulty(bp LevelBlueprint) (DifficultyReport, error) {
+	if err := bp.Validate(); err != nil {
+		return DifficultyReport{}, err
+	}
+
+	s, err := NewSolver(bp.Grid)
+	if err != nil {
+		return DifficultyReport{}, err
+	}
+	walks, err := s.ShortestPath(bp.StartingPoint, bp.FinishingPoint)
+	if err != nil {
+		return DifficultyReport{}, err
+	}
+
+	report := DifficultyReport{PathLength: len(walks)}
+
+	dirs := make([]command.Direction, len(walks))
+	run := 0
+	for ix, w := range walks {
+		dirs[ix] = w.Dir
+		if ix > 0 && w.Dir != walks[ix-1].Dir {
+			report.Turns++
+			run = 0
+		}
+		run++
+		report.LongestRun = max(report.LongestRun, run)
+	}
+
+	// A path cell has a way out towards dir when the paths, shifted the opposite way, land on it
+	paths := s.Paths()
+	var open [len(directions)]BitBoard
+	for ix, dir := range directions {
+		open[ix] = s.shift(paths, dir.Turn(command.Left).Turn(command.Left)).And(paths)
+	}
+	for cell := range paths.Cells() {
+		if !paths.Has(cell) {
+			continue
+		}
+		ways := 0
+		for _, board := range open {
+			if board.Has(cell) {
+				ways++
+			}
+		}
+		switch {
+		case ways == 1 && cell != bp.StartingPoint && cell != bp.FinishingPoint:
+			report.DeadEnds++
+		case ways >= 3:
+			report.Junctions++
+		}
+	}
+
+	// Every way between two cells that doesn't reach a new one closes a loop; valid levels have all of their paths connected
+	ways := paths.And(s.shift(paths, command.East)).Count() + paths.And(s.shift(paths, command.South)).Count()
+	report.Loops = ways - paths.Count() + 1
+
+	programs := [][]command.Action{arrows(dirs), steer(dirs, s.edges.Inward(s.walls.Empty().Set(bp.StartingPoint)))}
+	for _, actions := range programs {
+		if !allows(bp.Language, actions) {
+			continue
+		}
+		if blocks := fewestBlocks(actions, bp.Language, report.Blocks); report.Blocks == 0 || blocks < report.Blocks {
+			report.Blocks = blocks
+		}
+	}
+	if par := int(bp.WinCondition.ParBlocks); par > 0 && (report.Blocks == 0 || par < report.Blocks) {
+		report.Blocks = par
+	}
+
+	return report, nil
+}
+
+// arrows returns the actions walking dirs with an arrow per step.
+func arrows(dirs []command.Direction) []command.Action {
+	actions := make([]command.Action, len(dirs))
+	for ix, dir := range dirs {
+		actions[ix] = command.Walk{Dir: dir}
+	}
+	return actions
+}
+
+// steer returns the actions walking dirs by turning towards each step and moving forward, starting to face heading.
+func steer(dirs []command.Direction, heading command.Direction) []command.Action {
+	var actions []command.Action
+	for _, dir := range dirs {
+		switch dir {
+		case heading:
+		case heading.Turn(command.Left):
+			actions = append(actions, command.Turn{Rot: command.Left})
+		case heading.Turn(command.Right):
+			actions = append(actions, command.Turn{Rot: command.Right})
+		default:
+			actions = append(actions, command.Turn{Rot: command.Left}, command.Turn{Rot: command.Left})
+		}
+		heading = dir
+		actions = append(actions, command.Forward{})
+	}
+	return actions
+}
+
+// allows reports whether rules let a program perform every one of actions.
+func allows(rules command.Rules, actions []command.Action) bool {
+	for _, action := range actions {
+		if !rules.Allowed.Has(command.ActionFeature(action)) {
+			return false
+		}
+	}
+	return true
+}
+
+// fewestBlocks returns the size of the smallest program performing actions, shortened with repeats and a function
+// when rules allow them. Programs of bound blocks or more aren't searched for, so it returns bound when there is no smaller one
+// (0 = no bound).
+//
+// The functions tried are the stretches of actions that can be called more than once, or once from inside a repeat,
+// since that is the only way a single call makes a program smaller: the body of a function starts nested one level deep,
+// however deep it is called from, which lets it reach past MaxDepth.
+func fewestBlocks(actions []command.Action, rules command.Rules, bound int) int {
+	n := len(actions)
+	repeats, functions := rules.Allowed.Has(command.FeatureRepeat), rules.Allowed.Has(command.FeatureFunction)
+
+	// The main program and the function body can nest repeats this many levels deep, -1 for as deep as needed
+	nesting, inner := -1, -1
+	switch {
+	case !repeats:
+		nesting, inner = 0, 0
+	case rules.MaxDepth > 0:
+		nesting, inner = rules.MaxDepth, rules.MaxDepth-1
+	}
+
+	s := newStretches(actions)
+	best := s.smallest(0, n, nesting, stretch{})
+	if bound > 0 {
+		best = min(best, bound)
+	}
+	if !functions {
+		return best
+	}
+
+	for from := range n {
+		for to := from + 2; to <= n; to++ {
+			body := stretch{from, to - from}
+			if !s.first(body) || !(s.again(body) || nesting > 0) {
+				continue
+			}
+			// Defining the function and calling it takes two blocks besides its body
+			define := 1 + s.smallest(from, to, inner, stretch{})
+			if define+1 >= best {
+				continue
+			}
+			best = min(best, define+s.smallest(0, n, nesting, body))
+		}
+	}
+	return best
+}
+
+// stretch is a part of the actions searched by fewestBlocks, length actions long from start on.
+type stretch struct {
+	start, length int
+}
+
+// stretches finds which parts of a list of actions are the same.
+// same[p][x] is how many actions from x on match the ones p places after them,
+// so the stretch from x to x+length is made of a part p long repeated when same[p][x] >= length-p,
+// and matches the one p places after it when same[p][x] >= length.
+type stretches struct {
+	actions []command.Action
+	same    [][]int
+}
+
+func newStretches(actions []command.Action) stretches {
+	n := len(actions)
+	same := make([][]int, n)
+	for p := 1; p < n; p++ {
+		same[p] = make([]int, n+1)
+		for x := n - p - 1; x >= 0; x-- {
+			if actions[x] == actions[x+p] {
+				same[p][x] = same[p][x+1] + 1
+			}
+		}
+	}
+	return stretches{actions, same}
+}
+
+// equal reports whether the actions from a and b on are the same for length actions.
+func (s stretches) equal(a, b, length int) bool {
+	if a > b {
+		a, b = b, a
+	}
+	return a == b || s.same[b-a][a] >= length
+}
+
+// first reports whether no stretch starting before body holds the same actions.
+func (s stretches) first(body stretch) bool {
+	for x := range body.start {
+		if s.equal(x, body.start, body.length) {
+			return false
+		}
+	}
+	return true
+}
+
+// again reports whether the actions of body come again after it.
+func (s stretches) again(body stretch) bool {
+	for x := body.start + body.length; x+body.length <= len(s.actions); x++ {
+		if s.equal(x, body.start, body.length) {
+			return true
+		}
+	}
+	return false
+}
+
+// smallest returns the size of the smallest program performing the actions from `from` to `to`,
+// with repeats nested up to nesting levels deep (-1 for as deep as needed) and calls to a function performing call.
+func (s stretches) smallest(from, to, nesting int, call stretch) int {
+	return s.search(call).program(from, to, nesting)
+}
+
+// search finds the smallest programs for the stretches of actions, calling a function performing call.
+// Repeats can only go around stretches made of a part repeated, so only the programs for those parts are worked out,
+// and each one once: known holds them by where they start and end and how deep repeats can be nested in them.
+type search struct {
+	stretches
+	call  stretch
+	known map[[3]int]int
+}
+
+func (s stretches) search(call stretch) search {
+	return search{s, call, map[[3]int]int{}}
+}
+
+// program works out the smallest program performing the actions from `from` to `to`.
+// A program is made of statements one after the other, each one an action, a call when the actions are the same
+// as the function's, or a repeat around a part of what follows it, so the smallest program for the actions
+// up to each point comes from the ones for the points before it.
+func (s search) program(from, to, nesting int) int {
+	key := [3]int{from, to, nesting}
+	if blocks, ok := s.known[key]; ok {
+		return blocks
+	}
+
+	n := to - from
+	best := make([]int, n+1)
+	for x := 1; x <= n; x++ {
+		best[x] = n
+	}
+
+	for x := range n + 1 {
+		if x > 0 {
+			best[x] = min(best[x], best[x-1]+1)
+		}
+		if s.call.length > 0 && x >= s.call.length && s.equal(from+x-s.call.length, s.call.start, s.call.length) {
+			best[x] = min(best[x], best[x-s.call.length]+1)
+		}
+		if nesting == 0 {
+			continue
+		}
+
+		for p := 1; x+2*p <= n; p++ {
+			run := min(s.same[p][from+x]+p, n-x)
+			if run < 2*p {
+				continue
+			}
+			body := 1 + s.program(from+x, from+x+p, max(nesting-1, -1))
+			for times := 2; times*p <= run && times <= command.MaxRepeat; times++ {
+				best[x+times*p] = min(best[x+times*p], best[x]+body)
+			}
+		}
+	}
+
+	s.known[key] = best[n]
+	return best[n]
+}
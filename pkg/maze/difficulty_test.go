@@ -0,0 +1,137 @@
+package maze
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hkupty/mirkwood/pkg/command"
+)
+
+func TestDifficulty(t *testing.T) {
+	report, err := Difficulty(SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ↓↓↓→→↑↑→→↓→↓↓↓↓→, where `repetir` only pays off for the three and four steps down
+	expected := DifficultyReport{PathLength: 16, Turns: 7, LongestRun: 4, DeadEnds: 2, Junctions: 2, Loops: 0, Blocks: 13}
+	if report != expected {
+		t.Fatalf("expected %+v, got %+v", expected, report)
+	}
+
+	languages := []struct {
+		name   string
+		rules  command.Rules
+		par    uint16
+		blocks int
+	}{
+		{"loops", command.Rules{Allowed: command.StageLoops}, 0, 13},
+		// The path starts and ends the same way, so a function walks ↓↓↓→ at both ends
+		{"functions", command.Rules{Allowed: command.StageArrows | command.FeatureFunction}, 0, 15},
+		{"arrows", command.Rules{Allowed: command.StageArrows}, 0, 16},
+		// Starting on the north border facing south, the path turns seven times before its 16 steps forward
+		{"turtle", command.Rules{Allowed: command.FeatureTurn | command.FeatureForward}, 0, 23},
+		{"par below", command.Rules{}, 9, 9},
+		{"par above", command.Rules{}, 20, 13},
+		{"no moving", command.Rules{Allowed: command.FeatureMark}, 0, 0},
+	}
+	for _, tc := range languages {
+		bp := SampleBlueprint
+		bp.Language = tc.rules
+		bp.WinCondition.ParBlocks = tc.par
+		if report, err := Difficulty(bp); err != nil || report.Blocks != tc.blocks {
+			t.Fatalf("%s: expected %d blocks, got %+v (%v)", tc.name, tc.blocks, report, err)
+		}
+	}
+
+	// Opening the wall at 5:5 joins the corridor going down the middle to the one on the right
+	open := SampleBlueprint
+	open.Grid = make(MazeGrid, len(SampleMaze))
+	for ix, row := range SampleMaze {
+		open.Grid[ix] = append([]bool(nil), row...)
+	}
+	open.Grid[5][5] = false
+	if report, err := Difficulty(open); err != nil || report.Loops != 1 {
+		t.Fatalf("expected a loop, got %+v (%v)", report, err)
+	}
+
+	invalid := SampleBlueprint
+	invalid.FinishingPoint = 0
+	if _, err := Difficulty(invalid); !errors.Is(err, ErrMarkerOutOfPath) {
+		t.Fatalf("expected invalid levels not to be measured, got %v", err)
+	}
+}
+
+// smallest is a naive version of fewestBlocks, trying every way of writing path at each depth,
+// where fn is the body of the function that can be called (empty for none)
+func smallest(path, fn string, depth, maxDepth int, known map[string]int) int {
+	key := strings.Repeat(">", depth) + path
+	if blocks, ok := known[key]; ok {
+		return blocks
+	}
+
+	blocks := len(path)
+	if fn != "" && path == fn {
+		blocks = 1
+	}
+	for k := 1; k < len(path); k++ {
+		blocks = min(blocks, smallest(path[:k], fn, depth, maxDepth, known)+smallest(path[k:], fn, depth, maxDepth, known))
+	}
+	if maxDepth == 0 || depth < maxDepth {
+		for p := 1; p <= len(path)/2; p++ {
+			if len(path)%p == 0 && strings.Repeat(path[:p], len(path)/p) == path {
+				blocks = min(blocks, 1+smallest(path[:p], fn, depth+1, maxDepth, known))
+			}
+		}
+	}
+
+	known[key] = blocks
+	return blocks
+}
+
+// smallestWithFunction tries every part of path as the body of a function, which is nested a level deep
+func smallestWithFunction(path string, maxDepth int) int {
+	blocks := smallest(path, "", 0, maxDepth, map[string]int{})
+	for i := range len(path) {
+		for j := i + 2; j <= len(path); j++ {
+			fn := path[i:j]
+			define := 1 + smallest(fn, "", 1, maxDepth, map[string]int{})
+			blocks = min(blocks, define+smallest(path, fn, 0, maxDepth, map[string]int{}))
+		}
+	}
+	return blocks
+}
+
+func FuzzFewestBlocks(f *testing.F) {
+	f.Add([]byte{1, 1, 1, 2, 2, 0, 0, 2, 2, 1, 2, 1, 1, 1, 1, 2}, uint8(0), false)
+	f.Add([]byte{2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1}, uint8(1), false)
+	f.Add([]byte{2, 2, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1}, uint8(2), true)
+	f.Add([]byte{3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3}, uint8(3), false)
+	f.Add([]byte{0, 1, 2, 3, 0, 0, 0, 1, 2, 3, 2, 2}, uint8(0), true)
+	f.Add([]byte{0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1}, uint8(1), true)
+
+	f.Fuzz(func(t *testing.T, src []byte, depth uint8, functions bool) {
+		if len(src) > 12 {
+			src = src[:12]
+		}
+
+		// The naive version works on text, writing each direction as a digit
+		actions := make([]command.Action, len(src))
+		var path strings.Builder
+		for ix, b := range src {
+			actions[ix] = command.Walk{Dir: command.Direction(b % 4)}
+			path.WriteByte('0' + b%4)
+		}
+
+		rules := command.Rules{Allowed: command.StageLoops, MaxDepth: int(depth % 5)}
+		expected := smallest(path.String(), "", 0, rules.MaxDepth, map[string]int{})
+		if functions {
+			rules.Allowed |= command.FeatureFunction
+			expected = smallestWithFunction(path.String(), rules.MaxDepth)
+		}
+		if blocks := fewestBlocks(actions, rules, 0); blocks != expected {
+			t.Fatalf("expected %d blocks for %s nested up to %d, got %d (functions: %v)", expected, path.String(), rules.MaxDepth, blocks, functions)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package maze
+
+import "github.com/hkupty/mirkwood/pkg/command"
+
+// Edges holds the cells along each border of a board.
+// Shifting a position that sits on a border past it would either drop the bit (north/south)
+// or wrap it into the neighbouring row (east/west), so movement checks these masks before shifting.
+// They depend only on the board dimensions, so they are computed once per level.
+// The zero value marks no border at all, so it must come from NewEdges to be of any use (see IsZero).
+type Edges struct {
+	North BitBoard
+	South BitBoard
+	East  BitBoard
+	West  BitBoard
+}
+
+// NewEdges computes the border masks for a board with the same dimensions as board.
+func NewEdges(board BitBoard) Edges {
+	edges := Edges{
+		North: board.Empty(),
+		South: board.Empty(),
+		East:  board.Empty(),
+		West:  board.Empty(),
+	}
+
+	lastRow := board.Height() - 1
+	lastCol := board.Width() - 1
+
+	for col := range board.Width() {
+		edges.North = edges.North.Set(board.Bit(0, col))
+		edges.South = edges.South.Set(board.Bit(lastRow, col))
+	}
+
+	for row := range board.Height() {
+		edges.West = edges.West.Set(board.Bit(row, 0))
+		edges.East = edges.East.Set(board.Bit(row, lastCol))
+	}
+
+	return edges
+}
+
+// IsZero reports whether e was never computed: every board has cells along each of its borders.
+func (e Edges) IsZero() bool {
+	return e.West.IsZero()
+}
+
+// Inward returns the heading facing into the board from start when it sits on one of the borders,
+// or north otherwise.
+func (e Edges) Inward(start BitBoard) command.Direction {
+	switch {
+	case start.Intersects(e.North):
+		return command.South
+	case start.Intersects(e.South):
+		return command.North
+	case start.Intersects(e.West):
+		return command.East
+	case start.Intersects(e.East):
+		return command.West
+	default:
+		return command.North
+	}
+}
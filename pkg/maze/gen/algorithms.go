@@ -0,0 +1,150 @@
+package gen
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Each algorithm carves passages until every room is connected to every other one by exactly one path.
+// They grow the maze from the rooms already in it, which must be connected to each other:
+// a single random room for a plain maze, or a path shaped beforehand.
+
+// carver is the signature shared by the algorithms.
+type carver func(m *rooms, rng *rand.Rand, inMaze []bool)
+
+var carvers = [...]carver{
+	Backtracker: backtracker,
+	Prim:        prim,
+	Wilson:      wilson,
+}
+
+// backtracker walks from the rooms in the maze to random unvisited neighbours,
+// backing up to the last room with unvisited neighbours whenever it gets stuck.
+func backtracker(m *rooms, rng *rand.Rand, inMaze []bool) {
+	visited := inMaze
+	var stack []int
+	for ix, in := range inMaze {
+		if in {
+			stack = append(stack, ix)
+		}
+	}
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+
+		var unvisited []int
+		for _, next := range m.neighbours(current) {
+			if !visited[next] {
+				unvisited = append(unvisited, next)
+			}
+		}
+		if len(unvisited) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		next := unvisited[rng.IntN(len(unvisited))]
+		m.carve(current, next)
+		visited[next] = true
+		stack = append(stack, next)
+	}
+}
+
+// prim grows the maze, joining a random room of its frontier to it at each step.
+func prim(m *rooms, rng *rand.Rand, inMaze []bool) {
+	queued := make([]bool, m.count())
+	var frontier []int
+
+	join := func(ix int) {
+		inMaze[ix] = true
+		for _, next := range m.neighbours(ix) {
+			if !inMaze[next] && !queued[next] {
+				queued[next] = true
+				frontier = append(frontier, next)
+			}
+		}
+	}
+	for ix, in := range inMaze {
+		if in {
+			join(ix)
+		}
+	}
+
+	for len(frontier) > 0 {
+		pick := rng.IntN(len(frontier))
+		current := frontier[pick]
+		frontier[pick] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+
+		var joined []int
+		for _, next := range m.neighbours(current) {
+			if inMaze[next] {
+				joined = append(joined, next)
+			}
+		}
+		m.carve(current, joined[rng.IntN(len(joined))])
+		join(current)
+	}
+}
+
+// wilson walks randomly from each room outside of the maze until reaching it, carving the walk with its loops erased.
+func wilson(m *rooms, rng *rand.Rand, inMaze []bool) {
+	// exits remembers where the walk last left each room, so revisiting a room erases the loop since then
+	exits := make([]int, m.count())
+
+	for _, origin := range rng.Perm(m.count()) {
+		for current := origin; !inMaze[current]; current = exits[current] {
+			neighbours := m.neighbours(current)
+			exits[current] = neighbours[rng.IntN(len(neighbours))]
+		}
+
+		for current := origin; !inMaze[current]; current = exits[current] {
+			m.carve(current, exits[current])
+			inMaze[current] = true
+		}
+	}
+}
+
+// braid opens ratio of the dead ends of m, picked at random, into one of their neighbours,
+// returning how many passages were opened, each of them adding a loop to the maze.
+// Neighbours that are dead ends themselves are preferred, removing both dead ends with a single passage.
+func braid(m *rooms, rng *rand.Rand, ratio float64) int {
+	// Perfect mazes don't touch the generator, so they stay the same for the same seed
+	if ratio == 0 {
+		return 0
+	}
+
+	deadEnds := m.deadEnds()
+	rng.Shuffle(len(deadEnds), func(i, j int) {
+		deadEnds[i], deadEnds[j] = deadEnds[j], deadEnds[i]
+	})
+
+	opened := 0
+	for _, current := range deadEnds[:int(math.Round(ratio*float64(len(deadEnds))))] {
+		// An earlier passage may have already opened it
+		if m.links(current) != 1 {
+			continue
+		}
+
+		var closed, closedDeadEnds []int
+		for _, next := range m.neighbours(current) {
+			if m.linked(current, next) {
+				continue
+			}
+			closed = append(closed, next)
+			if m.links(next) == 1 {
+				closedDeadEnds = append(closedDeadEnds, next)
+			}
+		}
+		if len(closedDeadEnds) > 0 {
+			closed = closedDeadEnds
+		}
+		if len(closed) == 0 {
+			continue
+		}
+
+		m.carve(current, closed[rng.IntN(len(closed))])
+		opened++
+	}
+	return opened
+}
@@ -0,0 +1,312 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/core"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
+
+var (
+	// ErrUnknownConcept indicates a concept name that doesn't match any of the Concepts
+	ErrUnknownConcept = errors.New("unknown concept")
+
+	// ErrNoLesson indicates that no maze of the requested size could be shaped around the concept
+	ErrNoLesson = errors.New("no maze teaching the concept could be generated")
+)
+
+// Concept is what a generated level teaches, shaping the maze so that the construct behind it is the way to solve it.
+// Levels generated for a concept only allow the constructs learned so far, and their par is the size of a reference program.
+type Concept uint8
+
+const (
+	// AnyConcept generates a plain maze, with no particular lesson in mind
+	AnyConcept Concept = iota
+
+	// Repetition lays the way out as a staircase or a zigzag of straight runs, rewarding `repetir`
+	Repetition
+
+	// Conditions opens the start and finish on the border, where keeping a hand on a wall with `se parede` finds the way
+	Conditions
+
+	// Marks hides the finish among loops, where following a wall walks in circles unless cells are marked
+	Marks
+)
+
+// Concepts lists every concept, the first one being the default.
+var Concepts = []Concept{AnyConcept, Repetition, Conditions, Marks}
+
+var conceptNames = [...]string{
+	AnyConcept: "any",
+	Repetition: "repetition",
+	Conditions: "conditions",
+	Marks:      "marks",
+}
+
+func (c Concept) String() string {
+	if int(c) < len(conceptNames) {
+		return conceptNames[c]
+	}
+	return fmt.Sprintf("Concept(%d)", c)
+}
+
+// ParseConcept returns the concept with the given name, as written by Concept.String.
+func ParseConcept(name string) (Concept, error) {
+	for _, c := range Concepts {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownConcept, name)
+}
+
+// maxAttempts is how many mazes are shaped for a concept before giving up on finding one that teaches it
+const maxAttempts = 64
+
+// marksBraid is how braided mazes teaching Marks are, unless a braid ratio is given
+const marksBraid = 0.5
+
+// lesson describes how levels teaching a concept are generated and checked.
+type lesson struct {
+	// shape carves a maze around the concept, returning the level along with the source of its reference program,
+	// or false when the maze is too small for it
+	shape func(opts Options, rng *rand.Rand) (bp maze.LevelBlueprint, reference string, ok bool)
+
+	// allowed is what the player has learned by the time of the lesson
+	allowed command.Feature
+
+	// naive, when set, is a program that must not solve the level, proving the concept is needed
+	naive string
+}
+
+// Reference programs are written in the default dialect.
+const (
+	// rightHand keeps a hand on the wall to the right, which finds any exit on the border
+	rightHand = `enquanto não fim {
+  se parede_direita {
+    se parede { virar ← } senão { andar }
+  } senão {
+    virar →
+    andar
+  }
+}`
+
+	// markedHand keeps a hand on the wall to the right while walking new cells, marking them,
+	// but switches to the left hand when coming back to a marked cell, breaking out of the loop it was in
+	markedHand = `enquanto não fim {
+  se marcado {
+    se parede_esquerda {
+      se parede { virar → } senão { andar }
+    } senão {
+      virar ←
+      andar
+    }
+  } senão {
+    marcar
+    se parede_direita {
+      se parede { virar ← } senão { andar }
+    } senão {
+      virar →
+      andar
+    }
+  }
+}`
+)
+
+var lessons = map[Concept]lesson{
+	Repetition: {shape: repetition, allowed: command.StageLoops},
+	Conditions: {shape: conditions, allowed: command.StageConditionals | command.FeatureWhile},
+	Marks:      {shape: marks, allowed: command.StageMarks | command.FeatureWhile, naive: rightHand},
+}
+
+// teach generates a level for the concept in opts, trying new mazes until one is solved by the reference program,
+// and not by the naive one.
+func teach(opts Options, rng *rand.Rand) (maze.LevelBlueprint, Report, error) {
+	l, ok := lessons[opts.Concept]
+	if !ok {
+		return maze.LevelBlueprint{}, Report{}, fmt.Errorf("%w: %v", ErrUnknownConcept, opts.Concept)
+	}
+
+	for range maxAttempts {
+		bp, src, ok := l.shape(opts, rng)
+		if !ok {
+			break
+		}
+
+		reference, err := command.Parse(src)
+		if err != nil {
+			return maze.LevelBlueprint{}, Report{}, err
+		}
+		if !solves(bp, reference) || (l.naive != "" && solves(bp, mustParse(l.naive))) {
+			continue
+		}
+
+		bp.Language = command.Rules{Allowed: l.allowed}
+		if err := bp.Language.Check(reference); err != nil {
+			return maze.LevelBlueprint{}, Report{}, err
+		}
+		bp.WinCondition.ParBlocks = uint16(command.Measure(reference).Blocks)
+
+		report := Report{Reference: command.Format(reference, command.FormatOptions{})}
+		report.Cycles, report.DeadEnds = shapeOf(bp.Grid)
+		return bp, report, nil
+	}
+
+	return maze.LevelBlueprint{}, Report{}, fmt.Errorf("%w: %v in %dx%d rooms", ErrNoLesson, opts.Concept, opts.Cols, opts.Rows)
+}
+
+// solves reports whether program takes the player from the start to the finish of bp.
+func solves(bp maze.LevelBlueprint, program *command.Program) bool {
+	state, err := core.NewStateFromBlueprint(bp)
+	if err != nil {
+		return false
+	}
+	_, err = core.Run(state, program, command.DefaultLimits)
+	return err == nil
+}
+
+func mustParse(src string) *command.Program {
+	program, err := command.Parse(src)
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
+
+// shapeOf counts the loops and dead ends of a maze carved from rooms.
+func shapeOf(grid maze.MazeGrid) (cycles, deadEnds int) {
+	m := &rooms{rows: len(grid) / 2, cols: len(grid[0]) / 2, grid: grid}
+	passages := 0
+	for ix := range m.count() {
+		links := m.links(ix)
+		passages += links
+		if links == 1 {
+			deadEnds++
+		}
+	}
+	// Each passage was counted from both of its rooms, and a maze without loops has one less passage than rooms
+	return passages/2 - (m.count() - 1), deadEnds
+}
+
+// repetition lays out a staircase or a zigzag from the west opening of the first room, filling the rest of the maze around it.
+func repetition(opts Options, rng *rand.Rand) (maze.LevelBlueprint, string, bool) {
+	m := newRooms(opts.Rows, opts.Cols)
+	inMaze := make([]bool, m.count())
+	path := []int{0}
+
+	var reference string
+	shapes := []func() bool{
+		// A staircase of n steps, each going b rooms down and a rooms right
+		func() bool {
+			type step struct{ a, b, n int }
+			var steps []step
+			for a := 1; a <= 3; a++ {
+				for b := 1; b <= 3; b++ {
+					if n := min((opts.Cols-1)/a, (opts.Rows-1)/b); n >= 2 {
+						steps = append(steps, step{a, b, n})
+					}
+				}
+			}
+			if len(steps) == 0 {
+				return false
+			}
+
+			s := steps[rng.IntN(len(steps))]
+			for range s.n {
+				for range s.b {
+					path = append(path, path[len(path)-1]+opts.Cols)
+				}
+				for range s.a {
+					path = append(path, path[len(path)-1]+1)
+				}
+			}
+			reference = fmt.Sprintf("→\nrepetir %d {\n  repetir %d { ↓ }\n  repetir %d { → }\n}", s.n, 2*s.b, 2*s.a)
+			return true
+		},
+		// A zigzag going all the way right and back left k times, moving down a room at each end
+		func() bool {
+			k := (opts.Rows - 1) / 2
+			if k < 2 || opts.Cols < 2 {
+				return false
+			}
+
+			for row := range 2 * k {
+				for range opts.Cols - 1 {
+					if row%2 == 0 {
+						path = append(path, path[len(path)-1]+1)
+					} else {
+						path = append(path, path[len(path)-1]-1)
+					}
+				}
+				path = append(path, path[len(path)-1]+opts.Cols)
+			}
+			width := 2 * (opts.Cols - 1)
+			reference = fmt.Sprintf("→\nrepetir %d {\n  repetir %d { → }\n  ↓ ↓\n  repetir %d { ← }\n  ↓ ↓\n}", k, width, width)
+			return true
+		},
+	}
+
+	first := rng.IntN(len(shapes))
+	if !shapes[first]() && !shapes[1-first]() {
+		return maze.LevelBlueprint{}, "", false
+	}
+
+	inMaze[0] = true
+	for ix := 1; ix < len(path); ix++ {
+		m.carve(path[ix-1], path[ix])
+		inMaze[path[ix]] = true
+	}
+	carvers[opts.Algorithm](m, rng, inMaze)
+	braid(m, rng, opts.Braid)
+
+	start := m.open(opening{room: 0, side: command.West})
+	return m.blueprint(opts.Seed, start, m.room(path[len(path)-1])), reference, true
+}
+
+// conditions carves a maze with the start and finish on the border.
+func conditions(opts Options, rng *rand.Rand) (maze.LevelBlueprint, string, bool) {
+	m := newRooms(opts.Rows, opts.Cols)
+	inMaze := make([]bool, m.count())
+	inMaze[rng.IntN(m.count())] = true
+	carvers[opts.Algorithm](m, rng, inMaze)
+	braid(m, rng, opts.Braid)
+
+	start, finish := m.openings(rng)
+	return m.blueprint(opts.Seed, m.open(start), m.open(finish)), rightHand, true
+}
+
+// marks carves a braided maze with the start on the border and the finish in the room inside the maze farthest from it.
+func marks(opts Options, rng *rand.Rand) (maze.LevelBlueprint, string, bool) {
+	if opts.Rows < 3 || opts.Cols < 3 {
+		return maze.LevelBlueprint{}, "", false
+	}
+
+	m := newRooms(opts.Rows, opts.Cols)
+	inMaze := make([]bool, m.count())
+	inMaze[rng.IntN(m.count())] = true
+	carvers[opts.Algorithm](m, rng, inMaze)
+
+	ratio := opts.Braid
+	if ratio == 0 {
+		ratio = marksBraid
+	}
+	braid(m, rng, ratio)
+
+	border := m.border()
+	start := border[rng.IntN(len(border))]
+
+	distances := m.distances(start.room)
+	finish := -1
+	for ix, d := range distances {
+		r, c := ix/m.cols, ix%m.cols
+		inside := r > 0 && c > 0 && r < m.rows-1 && c < m.cols-1
+		if inside && (finish == -1 || d > distances[finish]) {
+			finish = ix
+		}
+	}
+
+	return m.blueprint(opts.Seed, m.open(start), m.room(finish)), markedHand, true
+}
@@ -0,0 +1,338 @@
+// Package gen generates maze levels from a seed, so the same seed always gives the same maze
+// and a whole class can play it by sharing a number.
+package gen
+
+// This package is responsible for:
+// - Carving perfect mazes (a single path between any two rooms) with different algorithms
+// - Braiding them, opening dead ends into loops
+// - Shaping them around a concept of the language, checked by running a reference program through the game
+// - Framing the maze and opening the start and finish on its border
+//
+// Generation works on plain grids; bitboards are only built from the result, by the game itself.
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
+
+var (
+	// ErrUnknownAlgorithm indicates an algorithm name that doesn't match any of the Algorithms
+	ErrUnknownAlgorithm = errors.New("unknown algorithm")
+
+	// ErrBraidRatio indicates a braid ratio that is not a fraction between 0 and 1
+	ErrBraidRatio = errors.New("braid ratio must be between 0 and 1")
+)
+
+// Algorithm chooses how passages are carved, which shapes how the maze feels to walk.
+type Algorithm uint8
+
+const (
+	// Backtracker walks randomly until stuck, then backs up, giving long winding corridors with few branches
+	Backtracker Algorithm = iota
+
+	// Prim grows the maze from a random room in every direction at once, giving many short dead ends
+	Prim
+
+	// Wilson joins loop-erased random walks, picking any perfect maze with the same chance, with no bias at all
+	Wilson
+)
+
+// Algorithms lists every algorithm, the first one being the default.
+var Algorithms = []Algorithm{Backtracker, Prim, Wilson}
+
+var algorithmNames = [...]string{
+	Backtracker: "backtracker",
+	Prim:        "prim",
+	Wilson:      "wilson",
+}
+
+func (a Algorithm) String() string {
+	if int(a) < len(algorithmNames) {
+		return algorithmNames[a]
+	}
+	return fmt.Sprintf("Algorithm(%d)", a)
+}
+
+// ParseAlgorithm returns the algorithm with the given name, as written by Algorithm.String.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for _, a := range Algorithms {
+		if a.String() == name {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+}
+
+// Options describes the maze to generate.
+type Options struct {
+	Algorithm Algorithm
+
+	// Seed picks one maze among all the ones the algorithm can generate, and becomes the Key of the level
+	Seed uint32
+
+	// Rows and Cols count rooms, not cells: the grid has a wall between neighbouring rooms and a frame around them,
+	// so it is 2*Rows+1 cells tall and 2*Cols+1 cells wide
+	Rows int
+	Cols int
+
+	// Concept shapes the maze around a construct of the language, for levels that are part of a lesson
+	Concept Concept
+
+	// Braid is the fraction of dead ends to remove by opening them into a neighbouring room, from 0 to 1.
+	// Every dead end removed adds a loop, so walking the maze can lead back to where it started,
+	// which is what marks are for. A perfect maze, with no loops at all, has a Braid of 0.
+	Braid float64
+}
+
+// Report describes the shape of a generated maze.
+type Report struct {
+	// Cycles is how many independent loops the maze has, one for each passage opened by braiding
+	Cycles int
+
+	// DeadEnds is how many rooms have a single way in, start and finish openings aside
+	DeadEnds int
+
+	// Reference is a program solving the level with the construct its Concept teaches, empty for AnyConcept
+	Reference string
+}
+
+// pcgStream is the second half of the generator state, fixed so that only the seed picks the maze
+const pcgStream = 0x6d69726b776f6f64 // "mirkwood"
+
+// Generate creates a level following opts, reporting the shape of its maze.
+// Unless braided, the maze is perfect, so there is exactly one path between the start and the finish,
+// which are openings in the frame placed as far from each other as the maze allows.
+// Returns maze.ErrEmptyBoard or maze.ErrLevelTooLarge when the grid can't hold the requested rooms.
+func Generate(opts Options) (maze.LevelBlueprint, Report, error) {
+	if opts.Rows <= 0 || opts.Cols <= 0 {
+		return maze.LevelBlueprint{}, Report{}, fmt.Errorf("%w: %dx%d rooms", maze.ErrEmptyBoard, opts.Cols, opts.Rows)
+	}
+	width, height := 2*opts.Cols+1, 2*opts.Rows+1
+	if width > 255 || height > 255 || width*height > maze.MaxCells {
+		return maze.LevelBlueprint{}, Report{}, fmt.Errorf("%w: %dx%d rooms", maze.ErrLevelTooLarge, opts.Cols, opts.Rows)
+	}
+	if !(opts.Braid >= 0 && opts.Braid <= 1) {
+		return maze.LevelBlueprint{}, Report{}, fmt.Errorf("%w: %v", ErrBraidRatio, opts.Braid)
+	}
+
+	rng := rand.New(rand.NewPCG(uint64(opts.Seed), pcgStream))
+
+	if int(opts.Algorithm) >= len(carvers) {
+		return maze.LevelBlueprint{}, Report{}, fmt.Errorf("%w: %v", ErrUnknownAlgorithm, opts.Algorithm)
+	}
+
+	if opts.Concept != AnyConcept {
+		return teach(opts, rng)
+	}
+
+	m := newRooms(opts.Rows, opts.Cols)
+	inMaze := make([]bool, m.count())
+	inMaze[rng.IntN(m.count())] = true
+	carvers[opts.Algorithm](m, rng, inMaze)
+
+	report := Report{Cycles: braid(m, rng, opts.Braid)}
+	report.DeadEnds = len(m.deadEnds())
+
+	start, finish := m.openings(rng)
+	return m.blueprint(opts.Seed, m.open(start), m.open(finish)), report, nil
+}
+
+// blueprint creates a level for the maze carved in m, going from the start to the finish cells.
+func (m *rooms) blueprint(seed uint32, start, finish uint16) maze.LevelBlueprint {
+	return maze.LevelBlueprint{
+		Key:            seed,
+		Grid:           m.grid,
+		StartingPoint:  start,
+		FinishingPoint: finish,
+		WinCondition:   maze.SimpleExit,
+	}
+}
+
+// rooms is the lattice a maze is carved from: room r, c sits at row 2r+1 and column 2c+1 of the grid,
+// and the cells between neighbouring rooms stay walls until a passage is carved through them.
+// Rooms are numbered row by row, as r*cols + c.
+type rooms struct {
+	rows int
+	cols int
+	grid maze.MazeGrid
+}
+
+func newRooms(rows, cols int) *rooms {
+	grid := make(maze.MazeGrid, 2*rows+1)
+	for ix := range grid {
+		grid[ix] = make([]bool, 2*cols+1)
+		for jx := range grid[ix] {
+			grid[ix][jx] = ix%2 == 0 || jx%2 == 0
+		}
+	}
+	return &rooms{rows: rows, cols: cols, grid: grid}
+}
+
+func (m *rooms) count() int {
+	return m.rows * m.cols
+}
+
+// center returns where room ix is in the grid.
+func (m *rooms) center(ix int) (row, col int) {
+	return 2*(ix/m.cols) + 1, 2*(ix%m.cols) + 1
+}
+
+// neighbours lists the rooms next to ix, whether there is a passage to them or not, always in the same order.
+func (m *rooms) neighbours(ix int) []int {
+	r, c := ix/m.cols, ix%m.cols
+	var found []int
+	if r > 0 {
+		found = append(found, ix-m.cols)
+	}
+	if r < m.rows-1 {
+		found = append(found, ix+m.cols)
+	}
+	if c > 0 {
+		found = append(found, ix-1)
+	}
+	if c < m.cols-1 {
+		found = append(found, ix+1)
+	}
+	return found
+}
+
+// carve opens a passage between the neighbouring rooms a and b.
+func (m *rooms) carve(a, b int) {
+	ra, ca := m.center(a)
+	rb, cb := m.center(b)
+	m.grid[(ra+rb)/2][(ca+cb)/2] = false
+}
+
+// linked reports whether there is a passage between the neighbouring rooms a and b.
+func (m *rooms) linked(a, b int) bool {
+	ra, ca := m.center(a)
+	rb, cb := m.center(b)
+	return !m.grid[(ra+rb)/2][(ca+cb)/2]
+}
+
+// links counts the passages out of room ix.
+func (m *rooms) links(ix int) int {
+	count := 0
+	for _, next := range m.neighbours(ix) {
+		if m.linked(ix, next) {
+			count++
+		}
+	}
+	return count
+}
+
+// deadEnds lists the rooms with a single passage out, in order.
+func (m *rooms) deadEnds() []int {
+	var found []int
+	for ix := range m.count() {
+		if m.links(ix) == 1 {
+			found = append(found, ix)
+		}
+	}
+	return found
+}
+
+// opening is a gap in the frame, right next to a room on the border of the maze.
+type opening struct {
+	room int
+	side command.Direction
+}
+
+// cell returns the bit position of the frame cell o opens.
+func (m *rooms) cell(o opening) uint16 {
+	row, col := m.center(o.room)
+	switch o.side {
+	case command.North:
+		row = 0
+	case command.South:
+		row = 2 * m.rows
+	case command.West:
+		col = 0
+	case command.East:
+		col = 2 * m.cols
+	}
+	return maze.PosToBit(uint8(2*m.cols+1), uint8(row), uint8(col))
+}
+
+// border lists every place the frame could be opened, always in the same order.
+func (m *rooms) border() []opening {
+	var found []opening
+	for ix := range m.count() {
+		r, c := ix/m.cols, ix%m.cols
+		if r == 0 {
+			found = append(found, opening{ix, command.North})
+		}
+		if r == m.rows-1 {
+			found = append(found, opening{ix, command.South})
+		}
+		if c == 0 {
+			found = append(found, opening{ix, command.West})
+		}
+		if c == m.cols-1 {
+			found = append(found, opening{ix, command.East})
+		}
+	}
+	return found
+}
+
+// openings picks a random start on the border, and the finish farthest away from it, also on the border.
+func (m *rooms) openings(rng *rand.Rand) (start, finish opening) {
+	border := m.border()
+	start = border[rng.IntN(len(border))]
+
+	distances := m.distances(start.room)
+	var farthest []opening
+	best := -1
+	for _, o := range border {
+		switch d := distances[o.room]; {
+		case o == start || d < best:
+		case d > best:
+			best, farthest = d, []opening{o}
+		default:
+			farthest = append(farthest, o)
+		}
+	}
+	finish = farthest[rng.IntN(len(farthest))]
+
+	return start, finish
+}
+
+// open opens o in the frame, returning the bit position of its cell.
+func (m *rooms) open(o opening) uint16 {
+	bit := m.cell(o)
+	row, col := maze.BitToPos(uint8(2*m.cols+1), bit)
+	m.grid[row][col] = false
+	return bit
+}
+
+// room returns the bit position of room ix.
+func (m *rooms) room(ix int) uint16 {
+	row, col := m.center(ix)
+	return maze.PosToBit(uint8(2*m.cols+1), uint8(row), uint8(col))
+}
+
+// distances counts how many rooms away from origin each room is, following the passages.
+func (m *rooms) distances(origin int) []int {
+	distances := make([]int, m.count())
+	for ix := range distances {
+		distances[ix] = -1
+	}
+	distances[origin] = 0
+
+	queue := []int{origin}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range m.neighbours(current) {
+			if distances[next] == -1 && m.linked(current, next) {
+				distances[next] = distances[current] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return distances
+}
@@ -0,0 +1,339 @@
+package gen
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/maze"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	// Sharing a seed must give everyone the same maze, on any machine and any run
+	bp, _, err := Generate(Options{Seed: 4711, Rows: 3, Cols: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := maze.FormatLevel(bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "" +
+		"   f   s\n" +
+		"  + +++ +++\n" +
+		"  + +   + +\n" +
+		"  + + + + +\n" +
+		"  + + + + +\n" +
+		"  + + + + +\n" +
+		"  +   +   +\n" +
+		"  +++++++++\n"
+	if string(out) != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+	if bp.Key != 4711 {
+		t.Fatalf("expected the seed to be the key, got %d", bp.Key)
+	}
+
+	for _, algorithm := range Algorithms {
+		opts := Options{Algorithm: algorithm, Seed: 4711, Rows: 7, Cols: 7, Braid: 0.5}
+		first, report, _ := Generate(opts)
+		second, again, _ := Generate(opts)
+		if !reflect.DeepEqual(first, second) || report != again {
+			t.Fatalf("%v generated different mazes for the same seed", algorithm)
+		}
+
+		opts.Seed++
+		if other, _, _ := Generate(opts); reflect.DeepEqual(first.Grid, other.Grid) {
+			t.Fatalf("%v generated the same maze for different seeds", algorithm)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     Options
+		expected error
+	}{
+		{"no rows", Options{Rows: 0, Cols: 3}, maze.ErrEmptyBoard},
+		{"negative cols", Options{Rows: 3, Cols: -1}, maze.ErrEmptyBoard},
+		{"too many rooms", Options{Rows: 16, Cols: 16}, maze.ErrLevelTooLarge},
+		{"too wide", Options{Rows: 1, Cols: 200}, maze.ErrLevelTooLarge},
+		{"unknown algorithm", Options{Algorithm: 9, Rows: 3, Cols: 3}, ErrUnknownAlgorithm},
+		{"negative braid", Options{Rows: 3, Cols: 3, Braid: -0.1}, ErrBraidRatio},
+		{"too much braid", Options{Rows: 3, Cols: 3, Braid: 1.5}, ErrBraidRatio},
+		{"braid is not a number", Options{Rows: 3, Cols: 3, Braid: math.NaN()}, ErrBraidRatio},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := Generate(tc.opts); !errors.Is(err, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	if _, err := ParseAlgorithm("kruskal"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Fatalf("expected ErrUnknownAlgorithm, got %v", err)
+	}
+	for _, algorithm := range Algorithms {
+		if parsed, err := ParseAlgorithm(algorithm.String()); err != nil || parsed != algorithm {
+			t.Fatalf("expected %v, got %v, %v", algorithm, parsed, err)
+		}
+	}
+}
+
+func FuzzGenerate(f *testing.F) {
+	f.Add(uint32(4711), uint8(Backtracker), uint8(7), uint8(7), uint8(0))
+	f.Add(uint32(0), uint8(Prim), uint8(1), uint8(1), uint8(0))
+	f.Add(uint32(1), uint8(Wilson), uint8(1), uint8(15), uint8(100))
+	f.Add(uint32(42), uint8(Wilson), uint8(15), uint8(15), uint8(50))
+	f.Add(uint32(7), uint8(Prim), uint8(2), uint8(9), uint8(100))
+
+	f.Fuzz(func(t *testing.T, seed uint32, algorithm, rows, cols, braid uint8) {
+		opts := Options{
+			Algorithm: Algorithms[int(algorithm)%len(Algorithms)],
+			Seed:      seed,
+			Rows:      int(rows%16) + 1,
+			Cols:      int(cols%16) + 1,
+			Braid:     float64(braid%101) / 100,
+		}
+		bp, report, err := Generate(opts)
+		if errors.Is(err, maze.ErrLevelTooLarge) {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		width, height := 2*opts.Cols+1, 2*opts.Rows+1
+		if w, h, err := bp.Grid.Size(); err != nil || w != width || h != height {
+			t.Fatalf("expected a %dx%d grid, got %dx%d (%v)", width, height, w, h, err)
+		}
+
+		// A perfect maze is a tree, with one passage less than there are rooms, and each braided passage adds a loop
+		passages := 0
+		for ix, row := range bp.Grid {
+			for jx, wall := range row {
+				if !wall && (ix%2 == 0) != (jx%2 == 0) && ix > 0 && jx > 0 && ix < height-1 && jx < width-1 {
+					passages++
+				}
+			}
+		}
+		if passages != opts.Rows*opts.Cols-1+report.Cycles {
+			t.Fatalf("expected %d passages with %d loops, got %d", opts.Rows*opts.Cols-1+report.Cycles, report.Cycles, passages)
+		}
+		if opts.Braid == 0 && report.Cycles != 0 {
+			t.Fatalf("expected a perfect maze, got %d loops", report.Cycles)
+		}
+
+		// Only the ends of a single corridor can't be opened into a neighbour
+		if opts.Braid == 1 && opts.Rows > 1 && opts.Cols > 1 && report.DeadEnds != 0 {
+			t.Fatalf("expected every dead end to be removed, got %d", report.DeadEnds)
+		}
+
+		for _, bit := range []uint16{bp.StartingPoint, bp.FinishingPoint} {
+			row, col := maze.BitToPos(uint8(width), bit)
+			onBorder := row == 0 || col == 0 || int(row) == height-1 || int(col) == width-1
+			if !onBorder || bp.Grid[row][col] {
+				t.Fatalf("expected an opening in the frame at %d:%d", row, col)
+			}
+		}
+
+		if err := bp.Validate(); err != nil {
+			t.Fatalf("generated level can't be played: %v", err)
+		}
+
+		// Every path cell is a room, a passage or one of the two openings
+		reachable := flood(bp.Grid, bp.StartingPoint)
+		if len(reachable) != 2*opts.Rows*opts.Cols+1+report.Cycles {
+			t.Fatalf("expected every room to be reachable from the start, got %d cells", len(reachable))
+		}
+		if !reachable[bp.FinishingPoint] {
+			t.Fatal("expected a path from the start to the finish")
+		}
+
+		// The level can be saved and loaded back
+		out, err := maze.FormatLevel(bp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := maze.ParseLevel(out)
+		if err != nil {
+			t.Fatalf("generated level can't be parsed: %v\n%s", err, out)
+		}
+		loaded.Key = bp.Key
+		if !reflect.DeepEqual(bp, loaded) {
+			t.Fatalf("generated level changed when loaded back:\n%s", out)
+		}
+	})
+}
+
+// flood returns every path cell reachable from origin, walking the grid directly.
+func flood(grid maze.MazeGrid, origin uint16) map[uint16]bool {
+	width := uint8(len(grid[0]))
+	reached := map[uint16]bool{origin: true}
+	queue := []uint16{origin}
+	for len(queue) > 0 {
+		row, col := maze.BitToPos(width, queue[0])
+		queue = queue[1:]
+		for _, step := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			r, c := int(row)+step[0], int(col)+step[1]
+			if r < 0 || c < 0 || r >= len(grid) || c >= len(grid[0]) || grid[r][c] {
+				continue
+			}
+			bit := maze.PosToBit(width, uint8(r), uint8(c))
+			if !reached[bit] {
+				reached[bit] = true
+				queue = append(queue, bit)
+			}
+		}
+	}
+	return reached
+}
+
+func TestBraid(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		opts := Options{Algorithm: algorithm, Seed: 4711, Rows: 7, Cols: 7}
+		perfect, _, err := Generate(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		previous := -1
+		for _, ratio := range []float64{0, 0.25, 0.5, 1} {
+			opts.Braid = ratio
+			_, report, err := Generate(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if previous != -1 && report.DeadEnds >= previous {
+				t.Fatalf("%v: expected braiding %v to leave fewer than %d dead ends, got %d", algorithm, ratio, previous, report.DeadEnds)
+			}
+			if ratio > 0 && report.Cycles == 0 {
+				t.Fatalf("%v: expected braiding %v to add loops", algorithm, ratio)
+			}
+			previous = report.DeadEnds
+		}
+
+		// Braiding only opens passages, so the perfect maze is still there underneath.
+		// The openings in the frame may move, as the finish is the farthest from the start.
+		opts.Braid = 1
+		braided, _, _ := Generate(opts)
+		for ix, row := range braided.Grid[1 : len(braided.Grid)-1] {
+			for jx, wall := range row[1 : len(row)-1] {
+				if wall && !perfect.Grid[ix+1][jx+1] {
+					t.Fatalf("%v: braiding closed the passage at %d:%d", algorithm, ix+1, jx+1)
+				}
+			}
+		}
+	}
+}
+
+func TestConcepts(t *testing.T) {
+	for _, concept := range Concepts[1:] {
+		for _, algorithm := range Algorithms {
+			t.Run(concept.String()+"/"+algorithm.String(), func(t *testing.T) {
+				bp, report, err := Generate(Options{Concept: concept, Algorithm: algorithm, Seed: 4711, Rows: 7, Cols: 7})
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkLesson(t, concept, bp, report)
+			})
+		}
+	}
+
+	// Repetition needs room for at least two steps, and marks need rooms inside the maze
+	for _, concept := range []Concept{Repetition, Marks} {
+		if _, _, err := Generate(Options{Concept: concept, Seed: 1, Rows: 2, Cols: 2}); !errors.Is(err, ErrNoLesson) {
+			t.Fatalf("%v: expected ErrNoLesson, got %v", concept, err)
+		}
+	}
+
+	if _, err := ParseConcept("recursion"); !errors.Is(err, ErrUnknownConcept) {
+		t.Fatalf("expected ErrUnknownConcept, got %v", err)
+	}
+	if _, _, err := Generate(Options{Concept: 9, Rows: 3, Cols: 3}); !errors.Is(err, ErrUnknownConcept) {
+		t.Fatalf("expected ErrUnknownConcept, got %v", err)
+	}
+}
+
+func FuzzConcepts(f *testing.F) {
+	f.Add(uint32(4711), uint8(Repetition), uint8(Backtracker), uint8(7), uint8(7), uint8(0))
+	f.Add(uint32(1), uint8(Conditions), uint8(Prim), uint8(1), uint8(9), uint8(30))
+	f.Add(uint32(2), uint8(Marks), uint8(Wilson), uint8(3), uint8(3), uint8(0))
+	f.Add(uint32(3), uint8(Repetition), uint8(Wilson), uint8(15), uint8(2), uint8(100))
+
+	f.Fuzz(func(t *testing.T, seed uint32, concept, algorithm, rows, cols, braid uint8) {
+		opts := Options{
+			Concept:   Concepts[1+int(concept)%(len(Concepts)-1)],
+			Algorithm: Algorithms[int(algorithm)%len(Algorithms)],
+			Seed:      seed,
+			Rows:      int(rows%16) + 1,
+			Cols:      int(cols%16) + 1,
+			Braid:     float64(braid%101) / 100,
+		}
+		bp, report, err := Generate(opts)
+		if errors.Is(err, maze.ErrLevelTooLarge) || errors.Is(err, ErrNoLesson) {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkLesson(t, opts.Concept, bp, report)
+
+		again, _, _ := Generate(opts)
+		if !reflect.DeepEqual(bp, again) {
+			t.Fatal("generated different levels for the same seed")
+		}
+	})
+}
+
+// checkLesson verifies a level teaching concept is solved by its reference program, and only with what it teaches.
+func checkLesson(t *testing.T, concept Concept, bp maze.LevelBlueprint, report Report) {
+	t.Helper()
+
+	reference, err := command.Parse(report.Reference)
+	if err != nil {
+		t.Fatalf("reference program doesn't parse: %v\n%s", err, report.Reference)
+	}
+	if err := bp.Language.Check(reference); err != nil {
+		t.Fatalf("reference program is not allowed in its own level: %v", err)
+	}
+	if int(bp.WinCondition.ParBlocks) != command.Measure(reference).Blocks {
+		t.Fatalf("expected the par to be the size of the reference program, got %d", bp.WinCondition.ParBlocks)
+	}
+	if !solves(bp, reference) {
+		t.Fatalf("reference program doesn't solve the level:\n%s", report.Reference)
+	}
+
+	switch concept {
+	case Repetition:
+		if bp.Language.Allowed.Has(command.FeatureIf) {
+			t.Fatal("expected conditions to be out of the lesson")
+		}
+	case Conditions:
+		if !bp.Language.Allowed.Has(command.FeatureIf) || bp.Language.Allowed.Has(command.FeatureMark) {
+			t.Fatal("expected conditions, but not marks, to be in the lesson")
+		}
+	case Marks:
+		if report.Cycles == 0 {
+			t.Fatal("expected loops to walk in circles")
+		}
+		if solves(bp, mustParse(rightHand)) {
+			t.Fatal("expected following a wall not to be enough")
+		}
+	}
+
+	out, err := maze.FormatLevel(bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := maze.ParseLevel(out); err != nil {
+		t.Fatalf("generated level can't be parsed: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,318 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Level files draw a maze using plain text, as in the files under `mazes/`:
+//
+//	 s
+//	+ +++++++
+//	+ +     +
+//	+++++++++
+//
+// `+` is a wall and a space is a path. The frame is the bounding box of all the walls,
+// so any indentation before it is ignored and short lines are padded with paths.
+// The `s` (start) and `f` (finish) markers may be drawn on a path cell inside the frame
+// or right outside of it, next to an opening in the border, in which case they refer to that opening.
+const (
+	WallRune   = '+'
+	PathRune   = ' '
+	StartRune  = 's'
+	FinishRune = 'f'
+)
+
+var (
+	// ErrUnexpectedRune indicates a character that is not part of the level format
+	ErrUnexpectedRune = errors.New("unexpected character")
+
+	// ErrNoWalls indicates the level has no walls, so there is no frame to read the maze from
+	ErrNoWalls = errors.New("level has no walls")
+
+	// ErrDuplicateMarker indicates the start or finish marker was drawn more than once
+	ErrDuplicateMarker = errors.New("marker appears more than once")
+
+	// ErrMissingMarker indicates the start or finish marker was not drawn
+	ErrMissingMarker = errors.New("marker is missing")
+
+	// ErrDetachedMarker indicates a marker outside the frame that doesn't touch its border
+	ErrDetachedMarker = errors.New("marker is not next to the maze border")
+
+	// ErrBlockedMarker indicates a marker outside the frame pointing at a wall instead of an opening
+	ErrBlockedMarker = errors.New("marker is not next to an opening")
+
+	// ErrOverlappingMarkers indicates the start and finish markers refer to the same cell
+	ErrOverlappingMarkers = errors.New("start and finish are on the same cell")
+
+	// ErrLevelTooLarge indicates the level doesn't fit in a BitBoard (see MaxCells)
+	ErrLevelTooLarge = errors.New("level is too large")
+
+	// ErrRaggedGrid indicates a grid whose rows don't all have the same length
+	ErrRaggedGrid = errors.New("grid rows have different lengths")
+
+	// ErrUnframedGrid indicates a grid with a border line without walls, which can't be told apart from padding
+	ErrUnframedGrid = errors.New("grid border has a line without walls")
+
+	// ErrMarkerOutOfPath indicates the start or finish is outside of the grid or on a wall
+	ErrMarkerOutOfPath = errors.New("marker is not on a path cell")
+)
+
+// ParseError reports a malformed level file, pointing at where the problem is.
+// Both Line and Column are 1-based, and Column counts characters, not bytes.
+type ParseError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// levelIndent is the indentation used when writing levels, leaving room for markers on the west border.
+const levelIndent = 2
+
+// marker records where in the file a marker was drawn (0-based).
+type marker struct {
+	line  int
+	col   int
+	found bool
+}
+
+// ReadLevel reads a level file from r and parses it with ParseLevel.
+func ReadLevel(r io.Reader) (LevelBlueprint, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return LevelBlueprint{}, err
+	}
+	return ParseLevel(src)
+}
+
+// WriteLevel writes bp to w in the level file format.
+func WriteLevel(w io.Writer, bp LevelBlueprint) error {
+	src, err := FormatLevel(bp)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// ParseLevel converts the text representation of a level into a LevelBlueprint.
+// The resulting blueprint has a SimpleExit win condition.
+// Levels that can't be played are rejected with the error from LevelBlueprint.Validate,
+// pointing at the finish that can't be reached or at the first path cell that can't.
+func ParseLevel(src []byte) (LevelBlueprint, error) {
+	lines := strings.Split(string(src), "\n")
+	rows := make([][]rune, len(lines))
+
+	var start, finish marker
+	top, left, bottom, right := -1, -1, -1, -1
+
+	for ln, line := range lines {
+		rows[ln] = []rune(strings.TrimSuffix(line, "\r"))
+		for col, r := range rows[ln] {
+			switch r {
+			case WallRune:
+				if top == -1 {
+					top, left, bottom, right = ln, col, ln, col
+				}
+				left = min(left, col)
+				right = max(right, col)
+				bottom = ln
+			case PathRune:
+			case StartRune, FinishRune:
+				m := &start
+				if r == FinishRune {
+					m = &finish
+				}
+				if m.found {
+					return LevelBlueprint{}, &ParseError{ln + 1, col + 1, fmt.Errorf("%w: %q", ErrDuplicateMarker, r)}
+				}
+				*m = marker{line: ln, col: col, found: true}
+			default:
+				return LevelBlueprint{}, &ParseError{ln + 1, col + 1, fmt.Errorf("%w: %q", ErrUnexpectedRune, r)}
+			}
+		}
+	}
+
+	if top == -1 {
+		return LevelBlueprint{}, ErrNoWalls
+	}
+	if !start.found {
+		return LevelBlueprint{}, fmt.Errorf("%w: %q", ErrMissingMarker, StartRune)
+	}
+	if !finish.found {
+		return LevelBlueprint{}, fmt.Errorf("%w: %q", ErrMissingMarker, FinishRune)
+	}
+
+	width := right - left + 1
+	height := bottom - top + 1
+
+	if err := checkSize(width, height); err != nil {
+		return LevelBlueprint{}, err
+	}
+
+	grid := make(MazeGrid, height)
+	for ix := range grid {
+		row := rows[top+ix]
+		grid[ix] = make([]bool, width)
+		for jx := range grid[ix] {
+			col := left + jx
+			grid[ix][jx] = col < len(row) && row[col] == WallRune
+		}
+	}
+
+	startPoint, err := locateMarker(grid, start, top, left)
+	if err != nil {
+		return LevelBlueprint{}, err
+	}
+	finishPoint, err := locateMarker(grid, finish, top, left)
+	if err != nil {
+		return LevelBlueprint{}, err
+	}
+	if startPoint == finishPoint {
+		return LevelBlueprint{}, &ParseError{finish.line + 1, finish.col + 1, ErrOverlappingMarkers}
+	}
+
+	bp := LevelBlueprint{
+		Grid:           grid,
+		StartingPoint:  startPoint,
+		FinishingPoint: finishPoint,
+		WinCondition:   SimpleExit,
+	}
+	if cell, err := bp.validate(); err != nil {
+		// Markers are blamed where they were drawn, which may be outside of the frame
+		line, column := 0, 0
+		switch cell {
+		case startPoint:
+			line, column = start.line, start.col
+		case finishPoint:
+			line, column = finish.line, finish.col
+		default:
+			row, col := BitToPos(uint8(width), cell)
+			line, column = top+int(row), left+int(col)
+		}
+		return LevelBlueprint{}, &ParseError{line + 1, column + 1, err}
+	}
+
+	return bp, nil
+}
+
+// locateMarker resolves the cell a marker refers to, as a bit position.
+// Markers outside the frame are moved onto the border cell right next to them.
+func locateMarker(grid MazeGrid, m marker, top, left int) (uint16, error) {
+	height := len(grid)
+	width := len(grid[0])
+	row := m.line - top
+	col := m.col - left
+
+	inRows := row >= 0 && row < height
+	inCols := col >= 0 && col < width
+
+	switch {
+	case inRows && inCols:
+	case inCols && row == -1:
+		row = 0
+	case inCols && row == height:
+		row = height - 1
+	case inRows && col == -1:
+		col = 0
+	case inRows && col == width:
+		col = width - 1
+	default:
+		return 0, &ParseError{m.line + 1, m.col + 1, ErrDetachedMarker}
+	}
+
+	if grid[row][col] {
+		return 0, &ParseError{m.line + 1, m.col + 1, ErrBlockedMarker}
+	}
+
+	return PosToBit(uint8(width), uint8(row), uint8(col)), nil
+}
+
+// FormatLevel converts a LevelBlueprint into its text representation, the inverse of ParseLevel.
+// Markers on the border are drawn outside of the frame, next to their opening,
+// while markers inside the maze are drawn on their own cell.
+// Only the grid and the markers are written; Key and WinCondition are not part of the format.
+func FormatLevel(bp LevelBlueprint) ([]byte, error) {
+	width, height, err := bp.Grid.Size()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+
+	top := slices.Contains(bp.Grid[0], true)
+	bottom := slices.Contains(bp.Grid[height-1], true)
+	var left, right bool
+	for _, row := range bp.Grid {
+		left = left || row[0]
+		right = right || row[width-1]
+	}
+	if !top || !bottom || !left || !right {
+		return nil, ErrUnframedGrid
+	}
+
+	if bp.StartingPoint == bp.FinishingPoint {
+		return nil, ErrOverlappingMarkers
+	}
+
+	// The canvas has an extra line above and below the frame, and an extra column on its right,
+	// so the markers for the border openings can be drawn outside of it.
+	canvas := make([][]rune, height+2)
+	for ix := range canvas {
+		canvas[ix] = []rune(strings.Repeat(string(PathRune), levelIndent+width+1))
+	}
+	for ix, row := range bp.Grid {
+		for jx, wall := range row {
+			if wall {
+				canvas[ix+1][levelIndent+jx] = WallRune
+			}
+		}
+	}
+
+	for _, m := range []struct {
+		bit  uint16
+		char rune
+	}{{bp.StartingPoint, StartRune}, {bp.FinishingPoint, FinishRune}} {
+		row, col := BitToPos(uint8(width), m.bit)
+		if int(row) >= height || int(col) >= width || bp.Grid[row][col] {
+			return nil, fmt.Errorf("%w: %q", ErrMarkerOutOfPath, m.char)
+		}
+
+		line, column := int(row)+1, levelIndent+int(col)
+		switch {
+		case row == 0:
+			line = 0
+		case int(row) == height-1:
+			line = height + 1
+		case col == 0:
+			column = levelIndent - 1
+		case int(col) == width-1:
+			column = levelIndent + width
+		}
+		canvas[line][column] = m.char
+	}
+
+	var out strings.Builder
+	for ix, line := range canvas {
+		text := strings.TrimRight(string(line), string(PathRune))
+		if text == "" && (ix == 0 || ix == len(canvas)-1) {
+			continue
+		}
+		out.WriteString(text)
+		out.WriteRune('\n')
+	}
+
+	return []byte(out.String()), nil
+}
@@ -0,0 +1,163 @@
+package maze
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLevelFile(t *testing.T) {
+	src, err := os.ReadFile("../../mazes/02.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bp, err := ParseLevel(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bp.Grid) != 7 || len(bp.Grid[0]) != 6 {
+		t.Fatalf("expected a 6x7 grid, got %dx%d", len(bp.Grid[0]), len(bp.Grid))
+	}
+
+	if bp.StartingPoint != PosToBit(6, 0, 1) {
+		t.Errorf("expected start at 0:1, got %d", bp.StartingPoint)
+	}
+
+	if bp.FinishingPoint != PosToBit(6, 6, 4) {
+		t.Errorf("expected finish at 6:4, got %d", bp.FinishingPoint)
+	}
+
+	expected := []bool{true, true, false, false, false, true}
+	for ix, wall := range expected {
+		if bp.Grid[3][ix] != wall {
+			t.Errorf("row 3 differs at column %d", ix)
+		}
+	}
+}
+
+func TestParseLargeLevelFile(t *testing.T) {
+	src, err := os.ReadFile("../../mazes/03.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bp, err := ParseLevel(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bp.Grid) != 13 || len(bp.Grid[0]) != 9 {
+		t.Fatalf("expected a 9x13 grid, got %dx%d", len(bp.Grid[0]), len(bp.Grid))
+	}
+
+	if bp.StartingPoint != PosToBit(9, 1, 0) {
+		t.Errorf("expected start at 1:0, got %d", bp.StartingPoint)
+	}
+
+	if bp.FinishingPoint != PosToBit(9, 11, 0) {
+		t.Errorf("expected finish at 11:0, got %d", bp.FinishingPoint)
+	}
+}
+
+func TestParseLevelErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		src    string
+		err    error
+		line   int
+		column int
+	}{
+		{"unexpected rune", " s\n+ ++\n+ x+\n++f+", ErrUnexpectedRune, 3, 3},
+		{"duplicate start", " s\n+ ++\n+s +\n++f+", ErrDuplicateMarker, 3, 2},
+		{"detached marker", "s\n + ++\n + ++\n ++f+", ErrDetachedMarker, 1, 1},
+		{"blocked marker", "  s\n+ ++\n+  +\n++f+", ErrBlockedMarker, 1, 3},
+		{"overlapping markers", " s\n+f++\n+  +\n++++", ErrOverlappingMarkers, 2, 2},
+		{"missing finish", " s\n+ ++\n+  +\n++++", ErrMissingMarker, 0, 0},
+		{"no walls", " s  f", ErrNoWalls, 0, 0},
+		{"too large", " s\n+ " + strings.Repeat("+", 300) + "\n+f", ErrLevelTooLarge, 0, 0},
+		{"unsolvable", " s\n+ +++\n+ + +\n+++f+", ErrUnsolvable, 4, 4},
+		{"disconnected", " s\n+ +++\n+   +\n+++ +\n+ + +\n+++f+", ErrDisconnected, 5, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseLevel([]byte(tc.src))
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				if tc.line != 0 {
+					t.Fatalf("expected a position in %v", err)
+				}
+				return
+			}
+
+			if parseErr.Line != tc.line || parseErr.Column != tc.column {
+				t.Errorf("expected error at %d:%d, got %d:%d", tc.line, tc.column, parseErr.Line, parseErr.Column)
+			}
+		})
+	}
+}
+
+func TestFormatSampleBlueprint(t *testing.T) {
+	src, err := FormatLevel(SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bp, err := ParseLevel(src)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+
+	if !reflect.DeepEqual(bp, SampleBlueprint) {
+		t.Fatalf("round trip changed the blueprint:\n%s", src)
+	}
+}
+
+func FuzzLevelRoundTrip(f *testing.F) {
+	files, _ := filepath.Glob("../../mazes/*.txt")
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(src))
+	}
+
+	sample, err := FormatLevel(SampleBlueprint)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(string(sample))
+	f.Add(" s\n+ ++\n+  f\n++++")
+	f.Add("++++\ns  +\n+ ++\n +f")
+
+	f.Fuzz(func(t *testing.T, src string) {
+		bp, err := ParseLevel([]byte(src))
+		if err != nil {
+			return
+		}
+
+		out, err := FormatLevel(bp)
+		if err != nil {
+			t.Fatalf("parsed level can't be written: %v", err)
+		}
+
+		again, err := ParseLevel(out)
+		if err != nil {
+			t.Fatalf("written level can't be parsed: %v\n%s", err, out)
+		}
+
+		if !reflect.DeepEqual(bp, again) {
+			t.Fatalf("round trip changed the blueprint:\n%s\n---\n%s", src, out)
+		}
+	})
+}
@@ -1,39 +1,64 @@
 package maze
 
-// BitBoard is a (max) 8x8 board linearized into a single integer for cache friendliness.
-// It can represent walls (1 = wall), visited paths, or marked cells.
-type BitBoard uint64
+import "github.com/hkupty/mirkwood/pkg/command"
 
 // MazeGrid is a matrix representation used for construction and analysis.
 // true = wall, false = path
 type MazeGrid [][]bool
 
+// Size returns the dimensions of the grid, making sure every row has the same length.
+func (g MazeGrid) Size() (width, height int, err error) {
+	height = len(g)
+	if height == 0 {
+		return 0, 0, ErrEmptyBoard
+	}
+
+	width = len(g[0])
+	for _, row := range g {
+		if len(row) != width {
+			return 0, 0, ErrRaggedGrid
+		}
+	}
+
+	return width, height, nil
+}
+
 // LevelBlueprint holds the static definition of a maze level.
 // It includes the grid layout, start/end positions, and win condition.
 type LevelBlueprint struct {
 	// Key identifies the level (file number, seed, etc.)
 	Key uint32
 
-	// Grid is the 8x8 maze layout (true = wall)
+	// Grid is the maze layout (true = wall), up to MaxCells
 	Grid MazeGrid
 
-	// StartingPoint is the bit position (0-63) where the player begins
-	StartingPoint uint8
+	// StartingPoint is the bit position (row*width + col) where the player begins
+	StartingPoint uint16
 
-	// FinishingPoint is the bit position (0-63) the player must reach
-	FinishingPoint uint8
+	// FinishingPoint is the bit position (row*width + col) the player must reach
+	FinishingPoint uint16
 
 	// WinCondition defines what must be satisfied to complete the level
 	WinCondition WinCondition
+
+	// Language restricts the programs that can be used to solve the level (zero value = anything goes)
+	Language command.Rules
 }
 
-// WinCondition specifies how a level is completed
+// WinCondition specifies how a level is completed.
 type WinCondition struct {
 	// RequiredMarks is the number of cells that must be marked (0 = no requirement)
 	RequiredMarks uint8
 
 	// MaxSteps is the maximum allowed steps (0 = unlimited)
 	MaxSteps uint16
+
+	// MaxBlocks is the largest program, in blocks (see command.Metrics), that completes the level (0 = unlimited).
+	// Unlike Language.MaxBlocks, larger programs still run, they only fail the level once they reach the finish.
+	MaxBlocks uint16
+
+	// ParBlocks is the size of a good solution in blocks (see command.Metrics), used to rate programs (0 = no par)
+	ParBlocks uint16
 }
 
 // WinCondition types for convenience
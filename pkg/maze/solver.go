@@ -0,0 +1,185 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hkupty/mirkwood/pkg/command"
+)
+
+var (
+	// ErrUnsolvable indicates a level whose finish can't be reached from its start
+	ErrUnsolvable = errors.New("finish can't be reached from the start")
+
+	// ErrDisconnected indicates a level with path cells that can't be reached from its start
+	ErrDisconnected = errors.New("some paths can't be reached from the start")
+)
+
+// directions are the ways a path can be walked, in the order ShortestPath tries them
+var directions = [...]command.Direction{command.North, command.South, command.East, command.West}
+
+// Solver finds the way around a level by flooding its paths.
+// Instead of visiting cells one by one, each step of the flood moves every cell reached so far to its neighbours at once,
+// shifting the whole board in each direction and masking out the walls, so a level is flooded in as many steps
+// as its farthest cell is away from where the flood started.
+type Solver struct {
+	walls BitBoard
+	edges Edges
+}
+
+// NewSolver creates a solver for the paths of grid.
+func NewSolver(grid MazeGrid) (Solver, error) {
+	walls, err := GridToBitBoard(grid)
+	if err != nil {
+		return Solver{}, err
+	}
+	return Solver{walls: walls, edges: NewEdges(walls)}, nil
+}
+
+// Paths returns every cell that is not a wall.
+func (s Solver) Paths() BitBoard {
+	return s.walls.Full().AndNot(s.walls)
+}
+
+// shift moves every cell of b one step towards dir.
+// Cells on the edge facing dir are dropped, instead of falling off the board or wrapping into the next row.
+func (s Solver) shift(b BitBoard, dir command.Direction) BitBoard {
+	row := uint16(b.Width())
+	switch dir {
+	case command.North:
+		return b.AndNot(s.edges.North).Shr(row)
+	case command.South:
+		return b.AndNot(s.edges.South).Shl(row)
+	case command.East:
+		return b.AndNot(s.edges.East).Shl(1)
+	default:
+		return b.AndNot(s.edges.West).Shr(1)
+	}
+}
+
+// spread returns the path cells one step away from any cell of b.
+func (s Solver) spread(b BitBoard) BitBoard {
+	next := b.Empty()
+	for _, dir := range directions {
+		next = next.Or(s.shift(b, dir))
+	}
+	return next.AndNot(s.walls)
+}
+
+// Layers floods the paths from bit, returning the cells at each distance from it:
+// the first layer is bit alone, and each following one holds the cells a step farther away.
+// The flood stops at the first layer reaching a cell of until, or once every reachable cell was visited.
+// Returns no layers at all when bit is not a path cell.
+func (s Solver) Layers(bit uint16, until BitBoard) []BitBoard {
+	if bit >= s.walls.Cells() || s.walls.Has(bit) {
+		return nil
+	}
+	frontier := s.walls.Empty().Set(bit)
+
+	visited := frontier
+	layers := []BitBoard{frontier}
+	for !frontier.Intersects(until) {
+		frontier = s.spread(frontier).AndNot(visited)
+		if frontier.IsZero() {
+			break
+		}
+		visited = visited.Or(frontier)
+		layers = append(layers, frontier)
+	}
+	return layers
+}
+
+// Reachable returns every path cell that can be walked to from bit, bit included.
+func (s Solver) Reachable(bit uint16) BitBoard {
+	reached := s.walls.Empty()
+	for _, layer := range s.Layers(bit, s.walls.Empty()) {
+		reached = reached.Or(layer)
+	}
+	return reached
+}
+
+// Distances counts how many steps away from bit each cell is, indexed by bit position.
+// Walls and cells that can't be reached are -1.
+func (s Solver) Distances(bit uint16) []int {
+	distances := make([]int, s.walls.Cells())
+	for ix := range distances {
+		distances[ix] = -1
+	}
+
+	for d, layer := range s.Layers(bit, s.walls.Empty()) {
+		for cell, ok := layer.Lowest(); ok; cell, ok = layer.Lowest() {
+			distances[cell] = d
+			layer = layer.Xor(layer.Empty().Set(cell))
+		}
+	}
+	return distances
+}
+
+// ShortestPath returns the walks taking from `from` to `to` in as few steps as possible.
+// When there is more than one such path, the same one is always returned.
+// Returns ErrUnsolvable if `to` can't be reached.
+func (s Solver) ShortestPath(from, to uint16) ([]command.Walk, error) {
+	target := s.walls.Empty().Set(to)
+	layers := s.Layers(from, target)
+	if len(layers) == 0 || !layers[len(layers)-1].Intersects(target) {
+		return nil, ErrUnsolvable
+	}
+
+	// Walk back from the target, finding a cell of the previous layer next to the current one at each step
+	walks := make([]command.Walk, len(layers)-1)
+	current := target
+	for d := len(layers) - 1; d > 0; d-- {
+		for _, dir := range directions {
+			if previous := s.shift(current, dir); previous.Intersects(layers[d-1]) {
+				walks[d-1] = command.Walk{Dir: dir.Turn(command.Left).Turn(command.Left)}
+				current = previous
+				break
+			}
+		}
+	}
+	return walks, nil
+}
+
+// Validate makes sure bp can be played: the start and finish are different path cells of a grid that fits in a board,
+// the finish can be reached from the start, and so can every other path cell,
+// since a part of the maze nobody can walk to is a mistake in the level.
+func (bp LevelBlueprint) Validate() error {
+	cell, err := bp.validate()
+	if errors.Is(err, ErrDisconnected) {
+		row, col := BitToPos(uint8(len(bp.Grid[0])), cell)
+		return fmt.Errorf("%w: cell %d:%d", err, row, col)
+	}
+	return err
+}
+
+// validate does the work of Validate, also returning the cell that makes bp unplayable,
+// so the error can point at it: the marker that is misplaced, the finish that can't be reached,
+// or the first path cell that can't be reached.
+func (bp LevelBlueprint) validate() (uint16, error) {
+	s, err := NewSolver(bp.Grid)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range []struct {
+		bit  uint16
+		char rune
+	}{{bp.StartingPoint, StartRune}, {bp.FinishingPoint, FinishRune}} {
+		if m.bit >= s.walls.Cells() || s.walls.Has(m.bit) {
+			return m.bit, fmt.Errorf("%w: %q", ErrMarkerOutOfPath, m.char)
+		}
+	}
+	if bp.StartingPoint == bp.FinishingPoint {
+		return bp.FinishingPoint, ErrOverlappingMarkers
+	}
+
+	reached := s.Reachable(bp.StartingPoint)
+	if !reached.Has(bp.FinishingPoint) {
+		return bp.FinishingPoint, ErrUnsolvable
+	}
+	if cell, ok := s.Paths().AndNot(reached).Lowest(); ok {
+		return cell, ErrDisconnected
+	}
+
+	return 0, nil
+}
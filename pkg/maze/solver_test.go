@@ -0,0 +1,182 @@
+package maze
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hkupty/mirkwood/pkg/command"
+)
+
+func TestShortestPath(t *testing.T) {
+	s, err := NewSolver(SampleMaze)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walks, err := s.ShortestPath(SampleBlueprint.StartingPoint, SampleBlueprint.FinishingPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var path strings.Builder
+	for _, w := range walks {
+		path.WriteString(w.String())
+	}
+	if expected := "↓↓↓→→↑↑→→↓→↓↓↓↓→"; path.String() != expected {
+		t.Fatalf("expected %s, got %s", expected, path.String())
+	}
+
+	if d := s.Distances(SampleBlueprint.StartingPoint)[SampleBlueprint.FinishingPoint]; d != len(walks) {
+		t.Fatalf("expected the finish %d steps away, got %d", len(walks), d)
+	}
+
+	// Walls can't be walked to
+	if _, err := s.ShortestPath(SampleBlueprint.StartingPoint, 0); !errors.Is(err, ErrUnsolvable) {
+		t.Fatalf("expected a wall to be unreachable, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// The start opens into a short corridor, and the cell at 2:3 is walled off from it
+	walled := MazeGrid{
+		{true, false, true, true, true},
+		{true, false, false, true, true},
+		{true, true, true, false, true},
+		{true, true, true, true, true},
+	}
+
+	cases := []struct {
+		name string
+		bp   LevelBlueprint
+		err  error
+	}{
+		{"sample", SampleBlueprint, nil},
+		{"unsolvable", LevelBlueprint{Grid: walled, StartingPoint: 1, FinishingPoint: PosToBit(5, 2, 3)}, ErrUnsolvable},
+		{"disconnected", LevelBlueprint{Grid: walled, StartingPoint: 1, FinishingPoint: PosToBit(5, 1, 2)}, ErrDisconnected},
+		{"start on a wall", LevelBlueprint{Grid: walled, StartingPoint: 0, FinishingPoint: 1}, ErrMarkerOutOfPath},
+		{"finish outside", LevelBlueprint{Grid: walled, StartingPoint: 1, FinishingPoint: 20}, ErrMarkerOutOfPath},
+		{"overlapping markers", LevelBlueprint{Grid: walled, StartingPoint: 1, FinishingPoint: 1}, ErrOverlappingMarkers},
+		{"ragged", LevelBlueprint{Grid: MazeGrid{{true, false}, {true}}}, ErrRaggedGrid},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.bp.Validate(); !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+// distances is a naive, cell-by-cell breadth-first search used to cross-check the flood
+func distances(grid MazeGrid, origin uint16) []int {
+	width, height := len(grid[0]), len(grid)
+	found := make([]int, width*height)
+	for ix := range found {
+		found[ix] = -1
+	}
+	if row, col := BitToPos(uint8(width), origin); grid[row][col] {
+		return found
+	}
+	found[origin] = 0
+
+	queue := []uint16{origin}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		row, col := BitToPos(uint8(width), current)
+		for _, next := range [][2]int{{int(row) - 1, int(col)}, {int(row) + 1, int(col)}, {int(row), int(col) + 1}, {int(row), int(col) - 1}} {
+			if next[0] < 0 || next[1] < 0 || next[0] >= height || next[1] >= width || grid[next[0]][next[1]] {
+				continue
+			}
+			bit := PosToBit(uint8(width), uint8(next[0]), uint8(next[1]))
+			if found[bit] == -1 {
+				found[bit] = found[current] + 1
+				queue = append(queue, bit)
+			}
+		}
+	}
+	return found
+}
+
+func FuzzSolver(f *testing.F) {
+	f.Add(uint8(8), uint8(8), uint64(0xFF2DA5B5B195C5FD), uint64(0), uint16(1), uint16(55))
+	f.Add(uint8(9), uint8(13), uint64(0xF0F0F0F0F0F0F0F0), uint64(0xFFFF), uint16(9), uint16(100))
+	f.Add(uint8(32), uint8(32), uint64(1), uint64(1<<63), uint16(65), uint16(1000))
+	f.Add(uint8(255), uint8(4), uint64(1<<63), uint64(3), uint16(255), uint16(0))
+
+	f.Fuzz(func(t *testing.T, width, height uint8, low, high uint64, from, to uint16) {
+		if checkSize(int(width), int(height)) != nil {
+			return
+		}
+
+		// Spread the fuzzed walls over the first and last rows of the grid, repeating them down the middle
+		grid := make(MazeGrid, height)
+		cells := int(width) * int(height)
+		for ix := range grid {
+			grid[ix] = make([]bool, width)
+			for jx := range grid[ix] {
+				bit := ix*int(width) + jx
+				switch {
+				case bit < 64:
+					grid[ix][jx] = low&(1<<bit) != 0
+				case bit >= cells-64:
+					grid[ix][jx] = high&(1<<(bit-cells+64)) != 0
+				default:
+					grid[ix][jx] = (low^high)&(1<<(bit%64)) != 0
+				}
+			}
+		}
+
+		s, err := NewSolver(grid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from, to = from%uint16(cells), to%uint16(cells)
+
+		expected := distances(grid, from)
+		got := s.Distances(from)
+		reached := s.Reachable(from)
+		for ix := range expected {
+			if got[ix] != expected[ix] {
+				t.Fatalf("expected cell %d to be %d steps away, got %d", ix, expected[ix], got[ix])
+			}
+			if reached.Has(uint16(ix)) != (expected[ix] != -1) {
+				t.Fatalf("expected cell %d reachable to be %v", ix, expected[ix] != -1)
+			}
+		}
+
+		walks, err := s.ShortestPath(from, to)
+		if expected[to] == -1 {
+			if !errors.Is(err, ErrUnsolvable) {
+				t.Fatalf("expected ErrUnsolvable, got %v", err)
+			}
+			return
+		}
+		if err != nil || len(walks) != expected[to] {
+			t.Fatalf("expected a path of %d steps, got %d (%v)", expected[to], len(walks), err)
+		}
+
+		// Following the path never steps on a wall or off the board
+		row, col := int(from)/int(width), int(from)%int(width)
+		for _, w := range walks {
+			switch w.Dir {
+			case command.North:
+				row--
+			case command.South:
+				row++
+			case command.East:
+				col++
+			case command.West:
+				col--
+			}
+			if row < 0 || col < 0 || row >= int(height) || col >= int(width) || grid[row][col] {
+				t.Fatalf("path %v leaves the paths at %d:%d", walks, row, col)
+			}
+		}
+		if PosToBit(width, uint8(row), uint8(col)) != to {
+			t.Fatalf("path %v ends at %d:%d", walks, row, col)
+		}
+	})
+}
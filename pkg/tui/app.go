@@ -1,23 +1,107 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hkupty/mirkwood/pkg/catalog"
 	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/core"
 	"github.com/hkupty/mirkwood/pkg/maze"
+	"github.com/hkupty/mirkwood/pkg/tui/components/codeview"
 	"github.com/hkupty/mirkwood/pkg/tui/components/mazeview"
+	"github.com/hkupty/mirkwood/pkg/tui/styles"
 )
 
+// stepDelay is how long each action of a running program stays on screen
+const stepDelay = 400 * time.Millisecond
+
+// stepMsg asks the running program to perform its next action.
+// It carries the run it was scheduled for, so steps left over from a run that was restarted are dropped.
+type stepMsg struct {
+	run int
+}
+
 type model struct {
-	maze mazeview.Model
+	blueprint maze.LevelBlueprint
+	dialect   *command.Dialect
+	catalog   *catalog.Catalog
+	maze      mazeview.Model
+	code      codeview.Model
+
+	// program and compiled are nil when there is no program, or it failed to parse
+	program  *command.Program
+	compiled *command.Code
+
+	// runner is the VM running the program, nil when not running
+	runner *command.VM
+
+	// run counts how many times the program was started, telling the steps of the current run apart
+	run int
+
+	// unfolded shows every action the program performs, one per line, when it can be known in advance
+	unfolded *unfoldedView
+
+	// status is the last message for the player, such as an error
+	status string
+}
+
+// keyActions maps the keys for playing manually to the actions they perform.
+// h/j/k/l walk in absolute directions, while a/d/w turn and walk relative to the heading.
+var keyActions = map[string]command.Action{
+	"h": command.Walk{Dir: command.West},
+	"j": command.Walk{Dir: command.South},
+	"k": command.Walk{Dir: command.North},
+	"l": command.Walk{Dir: command.East},
+	"a": command.Turn{Rot: command.Left},
+	"d": command.Turn{Rot: command.Right},
+	"w": command.Forward{},
+	"m": command.Mark{},
+}
+
+func initialModel(bp maze.LevelBlueprint, src string, dialect *command.Dialect, messages *catalog.Catalog) (model, error) {
+	view, err := mazeview.New(bp)
+	if err != nil {
+		return model{}, err
+	}
+
+	m := model{
+		blueprint: bp,
+		dialect:   dialect,
+		catalog:   messages,
+		maze:      view,
+	}
+
+	return m.load(src), nil
 }
 
-func initialModel() model {
-	return model{
-		maze: mazeview.New(maze.SampleBlueprint),
+// load replaces the player program with src, which is checked against the rules of the level.
+func (m model) load(src string) model {
+	m.code = codeview.New(src)
+	m.program, m.compiled, m.unfolded, m.status = nil, nil, nil, ""
+	if src == "" {
+		return m
 	}
+
+	program, err := m.dialect.Parse(src)
+	if err == nil {
+		err = m.blueprint.Language.Check(program)
+	}
+	if err == nil {
+		m.compiled, err = command.Compile(program)
+	}
+	if err != nil {
+		return m.fail(err)
+	}
+
+	m.program = program
+	m.unfolded = newUnfoldedView(program)
+	return m
 }
 
 func (m model) Init() tea.Cmd {
@@ -37,40 +121,43 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c", "q":
 			return m, tea.Quit
 
-		case "j":
-			newM, err := m.maze.Update(command.Walk{Dir: command.South})
-			if err != nil {
+		// Run the program from the start of the level
+		case "r":
+			if m.program == nil {
 				return m, nil
 			}
 
-			m.maze = newM
-
-		case "k":
-			newM, err := m.maze.Update(command.Walk{Dir: command.North})
+			view, err := mazeview.New(m.blueprint)
 			if err != nil {
 				return m, nil
 			}
 
-			m.maze = newM
+			m.maze = view
+			m.runner = command.NewVM(m.compiled, command.DefaultLimits)
+			m.run++
+			m.unfolded = m.unfolded.reset()
+			m.status = ""
+			return m, tick(m.run)
 
-		case "l":
-			newM, err := m.maze.Update(command.Walk{Dir: command.East})
-			if err != nil {
+		// Tidy up the program, so it is easier to read
+		case "f":
+			if m.runner != nil || m.program == nil {
 				return m, nil
 			}
 
-			m.maze = newM
+			return m.load(command.Format(m.program, command.FormatOptions{})), nil
 
-		case "h":
-			newM, err := m.maze.Update(command.Walk{Dir: command.West})
-			if err != nil {
+		default:
+			if m.runner != nil {
 				return m, nil
 			}
 
-			m.maze = newM
+			action, ok := keyActions[msg.String()]
+			if !ok || !m.blueprint.Language.Allowed.Has(command.ActionFeature(action)) {
+				return m, nil
+			}
 
-		case "m":
-			newM, err := m.maze.Update(command.Mark{})
+			newM, err := m.maze.Update(action)
 			if err != nil {
 				return m, nil
 			}
@@ -81,18 +168,111 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Return the updated model to the Bubble Tea runtime for processing.
 		// Note that we're not returning a command.
 		return m, nil
+
+	case stepMsg:
+		if msg.run != m.run {
+			return m, nil
+		}
+		return m.step()
+	}
+
+	return m, nil
+}
+
+// step performs the next action of the running program, scheduling the following one.
+func (m model) step() (tea.Model, tea.Cmd) {
+	if m.runner == nil {
+		return m, nil
+	}
+
+	action, done, err := m.runner.Next(m.maze.Sensors())
+	if err == nil && !done {
+		m.code = m.code.Highlight(m.runner.Origin().Span())
+		m.unfolded = m.unfolded.next()
+		m.maze, err = m.maze.Update(action)
+	}
+
+	switch {
+	case err != nil:
+		m = m.fail(err)
+	case done || m.maze.IsAtFinish():
+		rating := core.Rate(m.blueprint.WinCondition, command.Measure(m.program))
+		m.status = m.catalog.Text("tui.escaped", rating.Localize(m.catalog))
+		if err := m.maze.IsCompleteWith(command.Measure(m.program)); err != nil {
+			m.status = m.catalog.Render(err)
+		}
+	default:
+		return m, tick(m.run)
 	}
 
+	m.runner = nil
 	return m, nil
 }
 
-func (m model) View() string {
+// spanned is implemented by errors pointing at the code that caused them
+type spanned interface {
+	Span() command.Span
+}
+
+// fail shows err to the player, highlighting the offending code when known.
+func (m model) fail(err error) model {
+	m.status = m.catalog.Render(err)
+	var src spanned
+	if errors.As(err, &src) {
+		m.code = m.code.Highlight(src.Span())
+	}
+	return m
+}
+
+// tick schedules the next step of run.
+func tick(run int) tea.Cmd {
+	return tea.Tick(stepDelay, func(time.Time) tea.Msg {
+		return stepMsg{run: run}
+	})
+}
+
+// callPath describes the running calls, collapsing recursion so it fits in a line: `f › g ×3`
+func callPath(calls []*command.Call) string {
+	var names []string
+	for ix := 0; ix < len(calls); {
+		run := 1
+		for ix+run < len(calls) && calls[ix+run].Name == calls[ix].Name {
+			run++
+		}
+
+		name := calls[ix].Name
+		if run > 1 {
+			name += fmt.Sprintf(" ×%d", run)
+		}
+		names = append(names, name)
+		ix += run
+	}
+	return strings.Join(names, " › ")
+}
 
-	return m.maze.View()
+func (m model) View() string {
+	view := lipgloss.JoinHorizontal(lipgloss.Top, m.maze.View(), m.code.View(), m.unfolded.View())
+	if m.runner != nil {
+		if calls := m.runner.CallStack(); len(calls) > 0 {
+			view += "\n" + m.catalog.Text("tui.inside", callPath(calls))
+		}
+	}
+	if m.status != "" {
+		view += "\n" + lipgloss.NewStyle().Foreground(styles.ErrorFg).Render(m.status)
+	}
+	return view
 }
 
-func MainLoop() {
-	p := tea.NewProgram(initialModel())
+// MainLoop plays the level described by bp, running the player program in src when `r` is pressed.
+// The program is written in dialect, and messages for the player are written by messages.
+func MainLoop(bp maze.LevelBlueprint, src string, dialect *command.Dialect, messages *catalog.Catalog) {
+	m, err := initialModel(bp, src, dialect, messages)
+	if err != nil {
+		fmt.Print(messages.Text("tui.unplayable", messages.Render(err)))
+		os.Exit(1)
+	}
+
+	p := tea.NewProgram(m)
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
@@ -0,0 +1,44 @@
+package codeview
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/tui/styles"
+)
+
+// Model displays the player program, highlighting the part of it that is running.
+type Model struct {
+	src       string
+	highlight command.Span
+	active    bool
+}
+
+func New(src string) Model {
+	return Model{src: src}
+}
+
+// Highlight marks the given span of the source as running.
+func (m Model) Highlight(span command.Span) Model {
+	m.highlight = span
+	m.active = true
+	return m
+}
+
+// Clear removes the highlight.
+func (m Model) Clear() Model {
+	m.active = false
+	return m
+}
+
+func (m Model) View() string {
+	style := lipgloss.NewStyle().Padding(0, 2)
+	if !m.active {
+		return style.Render(m.src)
+	}
+
+	start := min(m.highlight.Start.Offset, len(m.src))
+	end := min(max(m.highlight.End.Offset, start), len(m.src))
+	running := lipgloss.NewStyle().Background(styles.RunningBg).Render(m.src[start:end])
+
+	return style.Render(m.src[:start] + running + m.src[end:])
+}
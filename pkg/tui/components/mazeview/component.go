@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hkupty/mirkwood/pkg/command"
 	"github.com/hkupty/mirkwood/pkg/core"
 	"github.com/hkupty/mirkwood/pkg/maze"
 	"github.com/hkupty/mirkwood/pkg/tui/composite"
@@ -15,13 +16,22 @@ type Model struct {
 	buffer composite.Buffer
 }
 
-func New(bp maze.LevelBlueprint) Model {
+func New(bp maze.LevelBlueprint) (Model, error) {
+	state, err := core.NewStateFromBlueprint(bp)
+	if err != nil {
+		return Model{}, err
+	}
+
+	buffer, err := composite.NewBuffer(int(state.Invariants.Walls.Width()), int(state.Invariants.Walls.Height()), 12, 5)
+	if err != nil {
+		return Model{}, err
+	}
+
 	model := Model{
-		state:  core.NewStateFromBlueprint(bp),
-		buffer: composite.NewBuffer(12, 5),
+		state:  state,
+		buffer: buffer,
 	}
-	state, _ := model.Update(nil)
-	return state
+	return model.Update(nil)
 }
 
 var trees = []string{
@@ -40,6 +50,14 @@ var chars = []string{
 	"",
 }
 
+// headings replaces the player glyph, pointing to where they are facing
+var headings = []string{
+	command.North: "▲",
+	command.South: "▼",
+	command.East:  "▶",
+	command.West:  "◀",
+}
+
 var bgArray = []lipgloss.Color{
 	styles.PathBg,
 	styles.PathBg,
@@ -54,7 +72,7 @@ var fgArray = []lipgloss.Color{
 	styles.VisitedMarkFg,
 }
 
-func (m Model) Update(action any) (Model, error) {
+func (m Model) Update(action command.Action) (Model, error) {
 	if action != nil {
 		state, err := core.Step(m.state, action)
 		if err != nil {
@@ -66,6 +84,26 @@ func (m Model) Update(action any) (Model, error) {
 	return m, nil
 }
 
+// Sensors exposes what a player program can sense from the current state.
+func (m Model) Sensors() command.Sensors {
+	return m.state.Sensors()
+}
+
+// IsAtFinish reports whether the player has reached the finishing point.
+func (m Model) IsAtFinish() bool {
+	return m.state.IsAtFinish()
+}
+
+// IsComplete checks the current state against the level's win condition.
+func (m Model) IsComplete() error {
+	return m.state.IsComplete()
+}
+
+// IsCompleteWith checks whether a program of the given size completed the level.
+func (m Model) IsCompleteWith(metrics command.Metrics) error {
+	return m.state.IsCompleteWith(metrics)
+}
+
 func (m Model) View() string {
 	var buffer strings.Builder
 	style := lipgloss.NewStyle()
@@ -79,7 +117,11 @@ func (m Model) View() string {
 				buffer.WriteString(style.Background(styles.WallBg).Foreground(styles.WallFg).Render(trees[shade]))
 			} else {
 				ix := (identity >> 2)
-				buffer.WriteString(style.Background(bgArray[ix]).Foreground(fgArray[ix]).Render(chars[identity&0b111]))
+				char := chars[identity&0b111]
+				if composite.CellIdentity(identity&0b11) == composite.Player {
+					char = headings[m.state.Heading]
+				}
+				buffer.WriteString(style.Background(bgArray[ix]).Foreground(fgArray[ix]).Render(char))
 			}
 
 		}
@@ -7,7 +7,10 @@ import (
 )
 
 func TestCanWriteMaze(t *testing.T) {
-	maze := New(maze.SampleBlueprint)
+	maze, err := New(maze.SampleBlueprint)
+	if err != nil {
+		t.Fatal(err)
+	}
 	str := maze.View()
 	if len(str) > 0 {
 		t.Fatal(len(str))
@@ -2,21 +2,23 @@ package composite
 
 import (
 	"github.com/hkupty/mirkwood/pkg/core"
+	"github.com/hkupty/mirkwood/pkg/shared"
 )
 
 func (buffer *Buffer) Contextualize(state core.State) {
-	for ix := range 64 {
-		logicalX := ix % 8
-		logicalY := ix / 8
-		var mask uint64 = 1 << ix
-		wall := (uint64(state.Invariants.Walls) & mask) >> ix
-		player := (uint64(state.Position) & mask) >> ix
-		marks := (uint64(state.Marks)&mask)>>ix == 1
-		visited := (uint64(state.VisitedPath)&mask)>>ix == 1
+	width := int(state.Invariants.Walls.Width())
+	for ix := range int(state.Invariants.Walls.Cells()) {
+		logicalX := ix % width
+		logicalY := ix / width
+		bit := uint16(ix)
+		wall := shared.BooltoInt(state.Invariants.Walls.Has(bit))
+		player := shared.BooltoInt(state.Position.Has(bit))
+		marks := state.Marks.Has(bit)
+		visited := state.VisitedPath.Has(bit)
 		contextCell := NewContextCell(CellIdentity(wall|(player<<1)), marks, visited)
 		if contextCell != buffer.Context[logicalY][logicalX] {
 			buffer.Context[logicalY][logicalX] = contextCell
-			buffer.Dirty |= mask
+			buffer.Dirty = buffer.Dirty.Set(bit)
 		}
 	}
 }
@@ -31,15 +33,15 @@ func (buffer *Buffer) Raster() {
 		if logicalY != 0 {
 			upperRow = buffer.Context[logicalY-1]
 		}
-		if logicalY < 7 {
+		if logicalY < len(buffer.Context)-1 {
 			lowerRow = buffer.Context[logicalY+1]
 		}
 
 		for logicalX, ctx := range row {
 			cellX := logicalX * buffer.XRes
-			offset := (logicalY*8 + logicalX)
+			offset := buffer.Dirty.Bit(uint8(logicalY), uint8(logicalX))
 
-			if (buffer.Dirty&(1<<offset))>>offset == 1 {
+			if buffer.Dirty.Has(offset) {
 
 				var topNeighbor *ContextCell
 				var botNeighbor *ContextCell
@@ -57,7 +59,7 @@ func (buffer *Buffer) Raster() {
 				if logicalX != 0 {
 					leftNeighbor = &row[logicalX-1]
 				}
-				if logicalX < 7 {
+				if logicalX < len(row)-1 {
 					rightNeighbor = &row[logicalX+1]
 				}
 
@@ -113,5 +115,5 @@ func (buffer *Buffer) Raster() {
 func (buffer *Buffer) Composite(state core.State) {
 	buffer.Contextualize(state)
 	buffer.Raster()
-	buffer.Dirty = 0 // Clear the flags for the next frame
+	buffer.Dirty = buffer.Dirty.Empty() // Clear the flags for the next frame
 }
@@ -1,10 +1,12 @@
 package composite
 
+import "github.com/hkupty/mirkwood/pkg/maze"
+
 type Buffer struct {
 	XRes    int
 	YRes    int
 	Context [][]ContextCell
-	Dirty   uint64
+	Dirty   maze.BitBoard
 	Cells   [][]Cell
 }
 
@@ -58,14 +60,21 @@ func NewCell(identity CellIdentity, marked bool, visited bool) Cell {
 	return cell
 }
 
-func NewBuffer(xres, yres int) Buffer {
-	row_size := xres * 8
-	col_size := yres * 8
-	context := make([][]ContextCell, 8)
-	context_rows := make([]ContextCell, 64)
+// NewBuffer creates a buffer for a board of width x height blocks,
+// where each block is xres x yres cells.
+func NewBuffer(width, height, xres, yres int) (Buffer, error) {
+	dirty, err := maze.NewBitBoard(width, height)
+	if err != nil {
+		return Buffer{}, err
+	}
+
+	row_size := xres * width
+	col_size := yres * height
+	context := make([][]ContextCell, height)
+	context_rows := make([]ContextCell, width*height)
 
-	for ix := range 8 {
-		context[ix] = context_rows[ix*8 : (ix+1)*8]
+	for ix := range height {
+		context[ix] = context_rows[ix*width : (ix+1)*width]
 	}
 
 	cells := make([][]Cell, col_size)
@@ -79,6 +88,7 @@ func NewBuffer(xres, yres int) Buffer {
 		XRes:    xres,
 		YRes:    yres,
 		Context: context,
+		Dirty:   dirty,
 		Cells:   cells,
-	}
+	}, nil
 }
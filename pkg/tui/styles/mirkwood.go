@@ -19,4 +19,6 @@ var (
 	MarkFg        = lipgloss.Color("#90EE90")
 	VisitedMarkFg = lipgloss.Color("#8EB173")
 	VisitedPathBg = lipgloss.Color("#634E3A")
+	RunningBg     = lipgloss.Color("#722D4F")
+	ErrorFg       = lipgloss.Color("#E06C75")
 )
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"github.com/hkupty/mirkwood/pkg/command"
+	"github.com/hkupty/mirkwood/pkg/tui/components/codeview"
+)
+
+// maxUnfolded is the most actions shown side by side with the program, which is about what fits on screen
+const maxUnfolded = 200
+
+// unfoldedView shows what a program really does, highlighting each action as it runs.
+// A nil view stands for a program that can't be unfolded, and shows nothing.
+type unfoldedView struct {
+	code codeview.Model
+
+	// steps holds where each action is in the unfolded code
+	steps []command.Span
+
+	// performed is how many actions already ran
+	performed int
+}
+
+// newUnfoldedView unfolds program, returning nil if it can't be shown as a list of actions.
+// The actions are written in the dialect of program, so they read the same as the code next to them.
+func newUnfoldedView(program *command.Program) *unfoldedView {
+	actions, err := command.Unfold(program, maxUnfolded)
+	if err != nil || len(actions) == 0 {
+		return nil
+	}
+
+	dialect := program.Dialect
+	if dialect == nil {
+		dialect = command.Dialects[0]
+	}
+
+	unfolded := &command.Program{Body: &command.Block{}, Dialect: dialect}
+	for _, action := range actions {
+		unfolded.Body.Stmts = append(unfolded.Body.Stmts, &command.Do{Action: action})
+	}
+	src := command.Format(unfolded, command.FormatOptions{Dialect: dialect})
+
+	// Reading the unfolded code back is the simplest way to know where each action is
+	flat, err := dialect.Parse(src)
+	if err != nil {
+		return nil
+	}
+
+	view := &unfoldedView{code: codeview.New(src)}
+	for _, stmt := range flat.Body.Stmts {
+		view.steps = append(view.steps, stmt.Span())
+	}
+	return view
+}
+
+// next highlights the action that is about to run.
+func (v *unfoldedView) next() *unfoldedView {
+	if v == nil || v.performed >= len(v.steps) {
+		return v
+	}
+
+	next := *v
+	next.code = v.code.Highlight(v.steps[v.performed])
+	next.performed++
+	return &next
+}
+
+// reset goes back to before the first action.
+func (v *unfoldedView) reset() *unfoldedView {
+	if v == nil {
+		return nil
+	}
+
+	next := *v
+	next.code = v.code.Clear()
+	next.performed = 0
+	return &next
+}
+
+func (v *unfoldedView) View() string {
+	if v == nil {
+		return ""
+	}
+	return v.code.View()
+}
@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkupty/mirkwood/pkg/command"
+)
+
+func TestUnfoldedViewKeepsDialect(t *testing.T) {
+	english, err := command.DialectFor("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	program, err := english.Parse("repeat 2 { forward turn → }\nmark")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view := newUnfoldedView(program)
+	if view == nil {
+		t.Fatal("expected the program to be unfolded")
+	}
+	if len(view.steps) != 5 {
+		t.Fatalf("expected 5 actions, got %d", len(view.steps))
+	}
+
+	text := view.View()
+	for _, word := range []string{"forward", "turn", "mark"} {
+		if !strings.Contains(text, word) {
+			t.Errorf("expected %q in the unfolded program:\n%s", word, text)
+		}
+	}
+	for _, word := range []string{"andar", "virar", "marcar"} {
+		if strings.Contains(text, word) {
+			t.Errorf("expected no %q in the unfolded program:\n%s", word, text)
+		}
+	}
+}